@@ -18,11 +18,14 @@ package cart
 
 import (
 	"fmt"
+	"github.com/shuLhan/go-mining/gain"
+	"github.com/shuLhan/go-mining/gain/entropy"
 	"github.com/shuLhan/go-mining/gain/gini"
 	"github.com/shuLhan/go-mining/tree/binary"
 	"github.com/shuLhan/numerus"
 	"github.com/shuLhan/tabula"
 	"github.com/shuLhan/tekstus"
+	"math/rand"
 	"os"
 	"strconv"
 )
@@ -33,6 +36,65 @@ const (
 	//
 	// This option is used in Runtime.SplitMethod.
 	SplitMethodGini = "gini"
+
+	// SplitMethodEntropy if defined in Runtime, the dataset will be
+	// splitted using information gain for each possible value or
+	// partition.
+	//
+	// This option is used in Runtime.SplitMethod.
+	SplitMethodEntropy = "entropy"
+
+	// SplitMethodGainRatio if defined in Runtime, the dataset will be
+	// splitted using gain ratio, as in C4.5, for each possible value or
+	// partition.
+	//
+	// This option is used in Runtime.SplitMethod.
+	SplitMethodGainRatio = "gainratio"
+
+	// SplitMethodVariance if defined in Runtime, the tree will be build as
+	// regression tree, where the class attribute must be numeric and the
+	// dataset will be splitted using reduction of variance.
+	//
+	// This option is used in Runtime.SplitMethod.
+	SplitMethodVariance = "variance"
+)
+
+const (
+	// LeafPredictMean set the predicted value in leaf of regression tree
+	// to mean of target values. This is the default.
+	//
+	// This option is used in Runtime.LeafPredict.
+	LeafPredictMean = "mean"
+
+	// LeafPredictMedian set the predicted value in leaf of regression
+	// tree to median of target values.
+	//
+	// This option is used in Runtime.LeafPredict.
+	LeafPredictMedian = "median"
+)
+
+const (
+	// PruneMethodHoldout if defined in Runtime, the samples will be
+	// splitted into growing set and pruning set. The tree is build using
+	// growing set, and the complexity parameter is selected by minimizing
+	// the error on pruning set.
+	//
+	// This option is used in Runtime.PruneMethod.
+	PruneMethodHoldout = "holdout"
+
+	// PruneMethodCV if defined in Runtime, the tree is build using all
+	// samples and the complexity parameter is selected using k-fold
+	// cross-validation.
+	//
+	// This option is used in Runtime.PruneMethod.
+	PruneMethodCV = "cv"
+
+	// DefPruneHoldout default percentage of samples that will be used as
+	// pruning set.
+	DefPruneHoldout = 33
+
+	// DefPruneNFold default number of fold in cross-validation.
+	DefPruneNFold = 10
 )
 
 const (
@@ -57,10 +119,59 @@ type Runtime struct {
 	// otherwise select n random feature and compute gain only on selected
 	// features.
 	NRandomFeature int `json:"NRandomFeature"`
+	// MaxDepth define the maximum depth of tree, where root is at depth
+	// zero. If its less or equal to zero, the depth is not limited.
+	MaxDepth int `json:"MaxDepth"`
+	// MinSamplesSplit define the minimum number of samples in node to be
+	// splitted.
+	MinSamplesSplit int `json:"MinSamplesSplit"`
+	// MinSamplesLeaf define the minimum number of samples in each leaf.
+	// The node will not be splitted if one of its child has less samples
+	// than this value.
+	MinSamplesLeaf int `json:"MinSamplesLeaf"`
+	// MinImpurityDecrease define the minimum weighted decrease of
+	// impurity for node to be splitted. The weighted decrease is computed
+	// as,
+	//
+	//	(number of samples in node / number of all samples) * gain
+	//
+	MinImpurityDecrease float64 `json:"MinImpurityDecrease"`
+	// ReuseContinuAttr if its true, the continuous attribute that has
+	// been used to split the parent node can be used again to split the
+	// child nodes. The discrete attribute can be used again only if its
+	// still have more than one value in node.
+	// If its false, the attribute that split the parent node is not used
+	// in the child nodes.
+	ReuseContinuAttr bool `json:"ReuseContinuAttr"`
+	// RandomSplit if its true, the continuous attribute is splitted using
+	// one random value between their minimum and maximum value in node,
+	// instead of searching the best value in all partitions, as in
+	// extremely randomized trees.
+	RandomSplit bool `json:"RandomSplit"`
+	// LeafPredict define how the value in leaf of regression tree is
+	// computed, its either mean or median of target values.
+	LeafPredict string `json:"LeafPredict"`
+	// PruneMethod define the method to select the complexity parameter
+	// in minimal cost-complexity pruning. If its empty, the tree will
+	// not be pruned.
+	PruneMethod string `json:"PruneMethod"`
+	// PruneHoldout define percentage of samples for pruning set, used
+	// only if PruneMethod is PruneMethodHoldout.
+	PruneHoldout int `json:"PruneHoldout"`
+	// PruneNFold define number of fold in cross-validation, used only if
+	// PruneMethod is PruneMethodCV.
+	PruneNFold int `json:"PruneNFold"`
 	// OOBErrVal is the last out-of-bag error value in the tree.
 	OOBErrVal float64
 	// Tree in classification.
 	Tree binary.Tree
+	// Rand if its not nil, will be used to select the random features
+	// instead of the global random source, so the tree can be rebuild
+	// with the same result using the same seed.
+	Rand *rand.Rand `json:"-"`
+
+	// nsample number of samples used to build the tree.
+	nsample int
 }
 
 func init() {
@@ -93,29 +204,101 @@ func New(D tabula.ClasetInterface, splitMethod string, nRandomFeature int) (
 
 /*
 Build will create a tree using CART algorithm.
+If PruneMethod is set, the tree will be pruned using minimal cost-complexity
+pruning.
 */
 func (runtime *Runtime) Build(D tabula.ClasetInterface) (e error) {
 	// Re-check input configuration.
 	switch runtime.SplitMethod {
-	case SplitMethodGini:
+	case SplitMethodGini, SplitMethodEntropy, SplitMethodGainRatio:
 		// Do nothing.
+	case SplitMethodVariance:
+		return runtime.buildRegression(D)
 	default:
 		// Set default split method to Gini index.
 		runtime.SplitMethod = SplitMethodGini
 	}
 
-	runtime.Tree.Root, e = runtime.splitTreeByGain(D)
+	switch runtime.PruneMethod {
+	case PruneMethodHoldout:
+		return runtime.buildPruneHoldout(D)
+	case PruneMethodCV:
+		return runtime.buildPruneCV(D)
+	}
+
+	runtime.Tree.Root, e = runtime.splitTreeByGain(D, 0)
 
 	return
 }
 
+//
+// isStopGrowing will return true if node with `nrow` samples at `depth`
+// should not be splitted anymore.
+// On root node, it will also save number of samples for computing the
+// weighted impurity decrease.
+//
+func (runtime *Runtime) isStopGrowing(nrow, depth int) bool {
+	if depth == 0 {
+		runtime.nsample = nrow
+	}
+	if runtime.MaxDepth > 0 && depth >= runtime.MaxDepth {
+		return true
+	}
+	if nrow < 2 || nrow < runtime.MinSamplesSplit {
+		return true
+	}
+	return false
+}
+
+//
+// isGainTooSmall will return true if the weighted gain on node with `nrow`
+// samples is less than MinImpurityDecrease.
+//
+func (runtime *Runtime) isGainTooSmall(nrow int, gain float64) bool {
+	if runtime.MinImpurityDecrease <= 0 || runtime.nsample <= 0 {
+		return false
+	}
+
+	decrease := float64(nrow) / float64(runtime.nsample) * gain
+
+	return decrease < runtime.MinImpurityDecrease
+}
+
+//
+// isLeafTooSmall will return true if one of the split has less samples than
+// MinSamplesLeaf.
+//
+func (runtime *Runtime) isLeafTooSmall(
+	splitL, splitR tabula.ClasetInterface,
+) bool {
+	return splitL.GetNRow() < runtime.MinSamplesLeaf ||
+		splitR.GetNRow() < runtime.MinSamplesLeaf
+}
+
+//
+// newClassLeaf create leaf node labeled with majority class in dataset.
+//
+func newClassLeaf(D tabula.ClasetInterface) *binary.BTNode {
+	class := D.MajorityClass()
+
+	return &binary.BTNode{
+		Value: NodeValue{
+			IsLeaf:     true,
+			Class:      class,
+			Size:       D.GetNRow(),
+			Miss:       countMiss(D, class),
+			ClassCount: countClass(D),
+		},
+	}
+}
+
 /*
 splitTreeByGain calculate the gain in all dataset, and split into two node:
 left and right.
 
 Return node with the split information.
 */
-func (runtime *Runtime) splitTreeByGain(D tabula.ClasetInterface) (
+func (runtime *Runtime) splitTreeByGain(D tabula.ClasetInterface, depth int) (
 	node *binary.BTNode,
 	e error,
 ) {
@@ -151,22 +334,29 @@ func (runtime *Runtime) splitTreeByGain(D tabula.ClasetInterface) (
 		}
 
 		node.Value = NodeValue{
-			IsLeaf: true,
-			Class:  name,
-			Size:   nrow,
+			IsLeaf:     true,
+			Class:      name,
+			Size:       nrow,
+			ClassCount: map[string]int{name: nrow},
 		}
 		return node, nil
 	}
 
+	// if node reach the stopping criteria, return node as leaf with
+	// majority class.
+	if runtime.isStopGrowing(nrow, depth) {
+		return newClassLeaf(D), nil
+	}
+
 	if DEBUG >= 2 {
 		fmt.Println("[cart] D:", D)
 	}
 
-	// calculate the Gini gain for each attribute.
+	// calculate the gain for each attribute.
 	gains := runtime.computeGain(D)
 
-	// get attribute with maximum Gini gain.
-	MaxGainIdx := gini.FindMaxGain(&gains)
+	// get attribute with maximum gain.
+	MaxGainIdx := gain.FindMaxGain(gains)
 	MaxGain := gains[MaxGainIdx]
 
 	// if maxgain value is 0, use majority class as node and terminate
@@ -178,16 +368,20 @@ func (runtime *Runtime) splitTreeByGain(D tabula.ClasetInterface) (
 				" and majority class is ", D.MajorityClass())
 		}
 
-		node.Value = NodeValue{
-			IsLeaf: true,
-			Class:  D.MajorityClass(),
-			Size:   0,
-		}
-		return node, nil
+		return newClassLeaf(D), nil
 	}
 
-	// using the sorted index in MaxGain, sort all field in dataset
-	tabula.SortColumnsByIndex(D, MaxGain.SortedIndex)
+	if runtime.isGainTooSmall(nrow, MaxGain.GetMaxInfoGainValue()) {
+		return newClassLeaf(D), nil
+	}
+
+	// using the sorted index in MaxGain, sort all field in dataset.
+	// If attribute has missing values, the sorted index does not cover
+	// all rows, so the dataset is not sorted.
+	sortedIdx := MaxGain.GetSortedIndex()
+	if len(sortedIdx) == nrow {
+		tabula.SortColumnsByIndex(D, sortedIdx)
+	}
 
 	if DEBUG >= 2 {
 		fmt.Println("[cart] maxgain:", MaxGain)
@@ -202,7 +396,7 @@ func (runtime *Runtime) splitTreeByGain(D tabula.ClasetInterface) (
 	// nominal values.
 	var splitV interface{}
 
-	if MaxGain.IsContinu {
+	if MaxGain.IsContinuous() {
 		splitV = MaxGain.GetMaxPartGainValue()
 	} else {
 		attrPartV := MaxGain.GetMaxPartGainValue()
@@ -215,58 +409,141 @@ func (runtime *Runtime) splitTreeByGain(D tabula.ClasetInterface) (
 		fmt.Println("[cart] split v:", splitV)
 	}
 
-	node.Value = NodeValue{
+	majorClass := D.MajorityClass()
+
+	nodev := NodeValue{
+		Class:         majorClass,
 		SplitAttrName: D.GetColumn(MaxGainIdx).GetName(),
 		IsLeaf:        false,
-		IsContinu:     MaxGain.IsContinu,
+		IsContinu:     MaxGain.IsContinuous(),
 		Size:          nrow,
+		Miss:          countMiss(D, majorClass),
 		SplitAttrIdx:  MaxGainIdx,
 		SplitV:        splitV,
+		ClassCount:    countClass(D),
+		Gain:          MaxGain.GetMaxInfoGainValue(),
+	}
+
+	splitL, splitR, e := runtime.splitNode(D, &nodev)
+	if e != nil {
+		return node, e
 	}
 
-	dsL, dsR, e := tabula.SplitRowsByValue(D, MaxGainIdx, splitV)
+	node.Value = nodev
 
+	// The split criterion only select the partition that satisfy
+	// MinSamplesLeaf on samples with known value, so the samples with
+	// missing value may still make one of the split too small.
+	if runtime.isLeafTooSmall(splitL, splitR) {
+		return newClassLeaf(D), nil
+	}
+
+	nodeLeft, e := runtime.splitTreeByGain(splitL, depth+1)
 	if e != nil {
 		return node, e
 	}
 
-	splitL := dsL.(tabula.ClasetInterface)
-	splitR := dsR.(tabula.ClasetInterface)
+	nodeRight, e := runtime.splitTreeByGain(splitR, depth+1)
+	if e != nil {
+		return node, e
+	}
 
-	// Set the flag to parent in attribute referenced by
-	// MaxGainIdx, so it will not computed again in the next round.
-	cols := splitL.GetColumns()
-	for x := range *cols {
-		if x == MaxGainIdx {
-			(*cols)[x].Flag = ColFlagParent
-		} else {
-			(*cols)[x].Flag = 0
-		}
+	node.SetLeft(nodeLeft)
+	node.SetRight(nodeRight)
+
+	return node, nil
+}
+
+//
+// splitByAttr will split the dataset into two subset using value `splitV` on
+// attribute `attrIdx`.
+//
+func splitByAttr(D tabula.ClasetInterface, attrIdx int, splitV interface{}) (
+	splitL, splitR tabula.ClasetInterface, e error,
+) {
+	dsL, dsR, e := tabula.SplitRowsByValue(D, attrIdx, splitV)
+	if e != nil {
+		return nil, nil, e
 	}
 
-	cols = splitR.GetColumns()
+	splitL = dsL.(tabula.ClasetInterface)
+	splitR = dsR.(tabula.ClasetInterface)
+
+	setParentFlag(splitL, attrIdx)
+	setParentFlag(splitR, attrIdx)
+
+	return splitL, splitR, nil
+}
+
+//
+// setParentFlag will set the flag to parent in attribute referenced by
+// attrIdx, so it will not computed again in the next round, and clear the
+// flag in other attributes.
+//
+func setParentFlag(D tabula.ClasetInterface, attrIdx int) {
+	cols := D.GetColumns()
 	for x := range *cols {
-		if x == MaxGainIdx {
+		if x == attrIdx {
 			(*cols)[x].Flag = ColFlagParent
 		} else {
 			(*cols)[x].Flag = 0
 		}
 	}
+}
 
-	nodeLeft, e := runtime.splitTreeByGain(splitL)
-	if e != nil {
-		return node, e
+//
+// countClass return number of samples for each class in dataset.
+//
+func countClass(D tabula.ClasetInterface) (counts map[string]int) {
+	counts = make(map[string]int)
+	for _, v := range D.GetClassAsStrings() {
+		counts[v]++
 	}
+	return
+}
 
-	nodeRight, e := runtime.splitTreeByGain(splitR)
-	if e != nil {
-		return node, e
+//
+// countMiss return number of samples in dataset which class is not equal to
+// `class`.
+//
+func countMiss(D tabula.ClasetInterface, class string) (miss int) {
+	for _, v := range D.GetClassAsStrings() {
+		if v != class {
+			miss++
+		}
 	}
+	return
+}
 
-	node.SetLeft(nodeLeft)
-	node.SetRight(nodeRight)
+//
+// isParentSkipped will return true if column is flagged as parent and can
+// not be used again to split the node.
+//
+func (runtime *Runtime) isParentSkipped(col *tabula.Column) bool {
+	if (col.Flag & ColFlagParent) != ColFlagParent {
+		return false
+	}
+	if !runtime.ReuseContinuAttr {
+		return true
+	}
+	if col.GetType() == tabula.TReal {
+		return false
+	}
 
-	return node, nil
+	// discrete attribute is skipped if all samples has the same value.
+	var first string
+	for _, rec := range col.Records {
+		if isMissing(rec, false) {
+			continue
+		}
+		v := rec.String()
+		if first == "" {
+			first = v
+		} else if v != first {
+			return false
+		}
+	}
+	return true
 }
 
 // SelectRandomFeature if NRandomFeature is greater than zero, select and
@@ -290,8 +567,8 @@ func (runtime *Runtime) SelectRandomFeature(D tabula.ClasetInterface) {
 	// exclude class index and parent node index
 	excludeIdx := []int{D.GetClassIndex()}
 	cols := D.GetColumns()
-	for x, col := range *cols {
-		if (col.Flag & ColFlagParent) == ColFlagParent {
+	for x := range *cols {
+		if runtime.isParentSkipped(&(*cols)[x]) {
 			excludeIdx = append(excludeIdx, x)
 		} else {
 			(*cols)[x].Flag |= ColFlagSkip
@@ -299,12 +576,9 @@ func (runtime *Runtime) SelectRandomFeature(D tabula.ClasetInterface) {
 	}
 
 	// Select random features excluding feature in `excludeIdx`.
-	var pickedIdx []int
-	for x := 0; x < runtime.NRandomFeature; x++ {
-		idx := numerus.IntPickRandPositive(ncols, false, pickedIdx,
-			excludeIdx)
-		pickedIdx = append(pickedIdx, idx)
+	pickedIdx := runtime.pickRandomFeature(ncols, excludeIdx)
 
+	for _, idx := range pickedIdx {
 		// Remove skip flag on selected column
 		col := D.GetColumn(idx)
 		col.Flag = col.Flag &^ ColFlagSkip
@@ -316,16 +590,112 @@ func (runtime *Runtime) SelectRandomFeature(D tabula.ClasetInterface) {
 	}
 }
 
+//
+// randomPartition return random value between minimum and maximum value in
+// `attr`, using Rand if its set or the global random source otherwise.
+// It will return false if all values in `attr` is equal.
+//
+func (runtime *Runtime) randomPartition(attr []float64) (
+	part float64, ok bool,
+) {
+	if len(attr) == 0 {
+		return 0, false
+	}
+
+	min, max := attr[0], attr[0]
+	for _, v := range attr[1:] {
+		if v < min {
+			min = v
+		} else if v > max {
+			max = v
+		}
+	}
+
+	if min == max {
+		return 0, false
+	}
+
+	var r float64
+	if runtime.Rand != nil {
+		r = runtime.Rand.Float64()
+	} else {
+		r = rand.Float64()
+	}
+
+	part = min + r*(max-min)
+
+	// Make sure at least one value is in the left partition.
+	if part <= min {
+		part = (min + max) / 2
+	}
+
+	return part, true
+}
+
+//
+// pickRandomFeature return NRandomFeature index of column, between zero and
+// `ncols`, excluding index in `excludeIdx`.
+// If Rand is set, the index is picked using it, otherwise the global random
+// source is used.
+//
+func (runtime *Runtime) pickRandomFeature(ncols int, excludeIdx []int) (
+	pickedIdx []int,
+) {
+	if runtime.Rand == nil {
+		for x := 0; x < runtime.NRandomFeature; x++ {
+			idx := numerus.IntPickRandPositive(ncols, false,
+				pickedIdx, excludeIdx)
+			pickedIdx = append(pickedIdx, idx)
+		}
+		return pickedIdx
+	}
+
+	var candidates []int
+	for x := 0; x < ncols; x++ {
+		if !numerus.IntsIsExist(excludeIdx, x) {
+			candidates = append(candidates, x)
+		}
+	}
+
+	for _, x := range runtime.Rand.Perm(len(candidates)) {
+		if len(pickedIdx) >= runtime.NRandomFeature {
+			break
+		}
+		pickedIdx = append(pickedIdx, candidates[x])
+	}
+
+	return pickedIdx
+}
+
+//
+// newGain create new split criterion based on SplitMethod.
+// The partition that has less samples than MinSamplesLeaf in one of their
+// side will not be selected by the split criterion.
+//
+func (runtime *Runtime) newGain() gain.Interface {
+	switch runtime.SplitMethod {
+	case SplitMethodEntropy:
+		return &entropy.Entropy{MinLeaf: runtime.MinSamplesLeaf}
+	case SplitMethodGainRatio:
+		return &entropy.Entropy{
+			IsRatio: true,
+			MinLeaf: runtime.MinSamplesLeaf,
+		}
+	}
+	return &gini.Gini{MinLeaf: runtime.MinSamplesLeaf}
+}
+
 /*
-computeGain calculate the gini index for each value in each attribute.
+computeGain calculate the gain, using split criterion in SplitMethod, for each
+value in each attribute.
 */
 func (runtime *Runtime) computeGain(D tabula.ClasetInterface) (
-	gains []gini.Gini,
+	gains []gain.Interface,
 ) {
-	switch runtime.SplitMethod {
-	case SplitMethodGini:
-		// create gains value for all attribute minus target class.
-		gains = make([]gini.Gini, D.GetNColumn())
+	// create gains value for all attribute.
+	gains = make([]gain.Interface, D.GetNColumn())
+	for x := range gains {
+		gains[x] = runtime.newGain()
 	}
 
 	runtime.SelectRandomFeature(D)
@@ -337,47 +707,94 @@ func (runtime *Runtime) computeGain(D tabula.ClasetInterface) (
 	for x, col := range *D.GetColumns() {
 		// skip class attribute.
 		if x == classIdx {
+			gains[x].SetSkip(true)
 			continue
 		}
 
 		// skip column flagged with parent
-		if (col.Flag & ColFlagParent) == ColFlagParent {
-			gains[x].Skip = true
+		if runtime.isParentSkipped(&col) {
+			gains[x].SetSkip(true)
 			continue
 		}
 
 		// ignore column flagged with skip
 		if (col.Flag & ColFlagSkip) == ColFlagSkip {
-			gains[x].Skip = true
+			gains[x].SetSkip(true)
 			continue
 		}
 
+		isContinu := col.GetType() == tabula.TReal
+
+		// the gain is computed only on rows with non-missing value.
+		known, hasMiss := knownIndex(col.Records, isContinu)
+
 		// compute gain.
-		if col.GetType() == tabula.TReal {
+		if isContinu {
 			attr := col.ToFloatSlice()
+			if hasMiss {
+				attr = selectFloats(attr, known)
+			}
+
+			// Gini has its own implementation for numeric class.
+			g, isGini := gains[x].(*gini.Gini)
 
-			if classType == tabula.TString {
+			if runtime.RandomSplit {
 				target := D.GetClassAsStrings()
-				gains[x].ComputeContinu(&attr, &target,
-					&classVS)
-			} else {
+				if hasMiss {
+					target = selectStrings(target, known)
+				}
+
+				part, ok := runtime.randomPartition(attr)
+				if !ok {
+					gains[x].SetSkip(true)
+					continue
+				}
+
+				switch g := gains[x].(type) {
+				case *gini.Gini:
+					g.ComputeContinuAt(&attr, &target,
+						&classVS, part)
+				case *entropy.Entropy:
+					g.ComputeContinuAt(&attr, &target,
+						&classVS, part)
+				}
+			} else if classType != tabula.TString && isGini {
 				targetReal := D.GetClassAsReals()
 				classVSReal := tekstus.StringsToFloat64(
 					classVS)
 
-				gains[x].ComputeContinuFloat(&attr,
+				if hasMiss {
+					targetReal = selectFloats(targetReal,
+						known)
+				}
+
+				g.ComputeContinuFloat(&attr,
 					&targetReal, &classVSReal)
+			} else {
+				target := D.GetClassAsStrings()
+				if hasMiss {
+					target = selectStrings(target, known)
+				}
+
+				gains[x].ComputeContinu(&attr, &target,
+					&classVS)
 			}
 		} else {
 			attr := col.ToStringSlice()
 			attrV := col.ValueSpace
+			target := D.GetClassAsStrings()
+
+			if hasMiss {
+				attr = selectStrings(attr, known)
+				attrV = knownValues(attrV)
+				target = selectStrings(target, known)
+			}
 
 			if DEBUG >= 2 {
 				fmt.Println("[cart] attr :", attr)
 				fmt.Println("[cart] attrV:", attrV)
 			}
 
-			target := D.GetClassAsStrings()
 			gains[x].ComputeDiscrete(&attr, &attrV, &target,
 				&classVS)
 		}
@@ -393,33 +810,50 @@ func (runtime *Runtime) computeGain(D tabula.ClasetInterface) (
 Classify return the prediction of one sample.
 */
 func (runtime *Runtime) Classify(data *tabula.Row) (class string) {
-	node := runtime.Tree.Root
+	node := findLeaf(runtime.Tree.Root, data)
+
+	return node.Value.(NodeValue).Class
+}
+
+/*
+ClassifyProba return the probability of each class for one sample, which is
+the proportion of each class in the leaf where the sample fall.
+*/
+func (runtime *Runtime) ClassifyProba(data *tabula.Row) (
+	probs map[string]float64,
+) {
+	nodev := findLeaf(runtime.Tree.Root, data).Value.(NodeValue)
+
+	return nodev.Proba()
+}
+
+/*
+Leaf return the leaf node where the sample fall.
+Two samples fall in the same leaf if their returned node is equal.
+*/
+func (runtime *Runtime) Leaf(data *tabula.Row) *binary.BTNode {
+	return findLeaf(runtime.Tree.Root, data)
+}
+
+//
+// findLeaf will walk the sample `data` from `node` down to the leaf and
+// return the leaf node.
+// If the value of split attribute is missing, the sample will be sent using
+// surrogate split or the majority direction in node.
+//
+func findLeaf(node *binary.BTNode, data *tabula.Row) *binary.BTNode {
 	nodev := node.Value.(NodeValue)
 
 	for !nodev.IsLeaf {
-		if nodev.IsContinu {
-			splitV := nodev.SplitV.(float64)
-			attrV := (*data)[nodev.SplitAttrIdx].Float()
-
-			if attrV < splitV {
-				node = node.Left
-			} else {
-				node = node.Right
-			}
+		if nodev.isLeft(data) {
+			node = node.Left
 		} else {
-			splitV := nodev.SplitV.([]string)
-			attrV := (*data)[nodev.SplitAttrIdx].String()
-
-			if tekstus.StringsIsContain(splitV, attrV) {
-				node = node.Left
-			} else {
-				node = node.Right
-			}
+			node = node.Right
 		}
 		nodev = node.Value.(NodeValue)
 	}
 
-	return nodev.Class
+	return node
 }
 
 /*
@@ -487,8 +921,9 @@ String yes, it will print it JSON like format.
 func (runtime *Runtime) String() (s string) {
 	s = fmt.Sprintf("NRandomFeature: %d\n"+
 		" SplitMethod   : %s\n"+
+		" PruneMethod   : %s\n"+
 		" Tree          :\n%v", runtime.NRandomFeature,
-		runtime.SplitMethod,
+		runtime.SplitMethod, runtime.PruneMethod,
 		runtime.Tree.String())
 	return s
 }
@@ -5,12 +5,17 @@
 package cart_test
 
 import (
+	"bytes"
 	"fmt"
 	"github.com/shuLhan/dsv"
 	"github.com/shuLhan/go-mining/classifier/cart"
+	"github.com/shuLhan/go-mining/gain/variance"
+	"github.com/shuLhan/go-mining/math"
+	"github.com/shuLhan/go-mining/tree/binary"
 	"github.com/shuLhan/tabula"
 	"reflect"
 	"runtime/debug"
+	"strings"
 	"testing"
 )
 
@@ -70,3 +75,571 @@ func TestCART(t *testing.T) {
 
 	assert(t, targetv, testset.GetClassAsStrings(), true)
 }
+
+func TestSaveLoad(t *testing.T) {
+	fds := "../../testdata/iris/iris.dsv"
+
+	ds := tabula.Claset{}
+	_, e := dsv.SimpleRead(fds, &ds)
+	if nil != e {
+		t.Fatal(e)
+	}
+
+	CART, e := cart.New(&ds, cart.SplitMethodGini, 0)
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	buf := bytes.Buffer{}
+
+	e = CART.Save(&buf)
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	loaded := cart.Runtime{}
+
+	e = loaded.Load(&buf)
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	assert(t, CART.SplitMethod, loaded.SplitMethod, true)
+	assert(t, CART.Tree.String(), loaded.Tree.String(), true)
+
+	testset := tabula.Claset{}
+	_, e = dsv.SimpleRead(fds, &testset)
+	if nil != e {
+		t.Fatal(e)
+	}
+
+	for x := 0; x < testset.GetNRow(); x++ {
+		row := testset.GetRow(x)
+		assert(t, CART.Classify(row), loaded.Classify(row), true)
+	}
+
+	// Model without root node must be rejected.
+	for _, in := range []string{
+		`{"Version":1,"SplitMethod":"gini"}`,
+		`{"Version":1,"SplitMethod":"gini","Root":null}`,
+	} {
+		e = loaded.Load(strings.NewReader(in))
+		assert(t, cart.ErrModelRoot, e, true)
+	}
+}
+
+func TestCostComplexityPath(t *testing.T) {
+	fds := "../../testdata/forensic_glass/fgl.dsv"
+
+	ds := tabula.Claset{}
+	_, e := dsv.SimpleRead(fds, &ds)
+	if nil != e {
+		t.Fatal(e)
+	}
+
+	CART, e := cart.New(&ds, cart.SplitMethodGini, 0)
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	steps := CART.CostComplexityPath()
+	if len(steps) == 0 {
+		t.Fatal("Expecting pruning sequence, got empty")
+	}
+
+	for x := 1; x < len(steps); x++ {
+		if steps[x].Alpha < steps[x-1].Alpha {
+			t.Fatalf("Expecting increasing alpha, got %f < %f",
+				steps[x].Alpha, steps[x-1].Alpha)
+		}
+		if steps[x].NLeaf >= steps[x-1].NLeaf {
+			t.Fatalf("Expecting decreasing leaves, got %d >= %d",
+				steps[x].NLeaf, steps[x-1].NLeaf)
+		}
+	}
+
+	assert(t, 1, steps[len(steps)-1].NLeaf, true)
+}
+
+func TestPruneCV(t *testing.T) {
+	fds := "../../testdata/forensic_glass/fgl.dsv"
+
+	ds := tabula.Claset{}
+	_, e := dsv.SimpleRead(fds, &ds)
+	if nil != e {
+		t.Fatal(e)
+	}
+
+	CART := cart.Runtime{
+		SplitMethod: cart.SplitMethodGini,
+	}
+
+	e = CART.Build(&ds)
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	nleaf := CART.Tree.Root.LeafCount()
+	steps := CART.CostComplexityPath()
+
+	cvset := tabula.Claset{}
+	_, e = dsv.SimpleRead(fds, &cvset)
+	if nil != e {
+		t.Fatal(e)
+	}
+
+	alpha, e := CART.PruneByCV(&cvset, 5)
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	found := false
+	for _, step := range steps {
+		if step.Alpha == alpha {
+			found = true
+			break
+		}
+	}
+	if !found {
+		t.Fatalf("Expecting alpha %f in cost-complexity path", alpha)
+	}
+
+	got := CART.Tree.Root.LeafCount()
+	if got > nleaf {
+		t.Fatalf("Expecting pruned leaves less or equal to %d, got %d",
+			nleaf, got)
+	}
+
+	// Build and prune the tree using PruneMethodCV.
+	pruned := cart.Runtime{
+		PruneMethod: cart.PruneMethodCV,
+		PruneNFold:  5,
+	}
+
+	ds = tabula.Claset{}
+	_, e = dsv.SimpleRead(fds, &ds)
+	if nil != e {
+		t.Fatal(e)
+	}
+
+	e = pruned.Build(&ds)
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	got = pruned.Tree.Root.LeafCount()
+	if got >= nleaf {
+		t.Fatalf("Expecting pruned leaves less than %d, got %d",
+			nleaf, got)
+	}
+
+	found = false
+	for _, step := range steps {
+		if step.NLeaf == got {
+			found = true
+			break
+		}
+	}
+	if !found {
+		t.Fatalf("Expecting %d leaves in cost-complexity path", got)
+	}
+
+	fmt.Println("[cart_test] Pruned tree:\n", &pruned)
+}
+
+func TestRegression(t *testing.T) {
+	fds := "../../testdata/forensic_glass/glass_ri.dsv"
+
+	ds := tabula.Claset{}
+	_, e := dsv.SimpleRead(fds, &ds)
+	if nil != e {
+		t.Fatal(e)
+	}
+
+	CART, e := cart.New(&ds, cart.SplitMethodVariance, 0)
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	testset := tabula.Claset{}
+	_, e = dsv.SimpleRead(fds, &testset)
+	if nil != e {
+		t.Fatal(e)
+	}
+
+	actuals := testset.GetClassAsReals()
+	predicts := make([]float64, len(actuals))
+
+	rows := testset.GetRows()
+	for x, row := range *rows {
+		predicts[x] = CART.Predict(row)
+	}
+
+	rmse := math.RMSE(actuals, predicts)
+	mae := math.MAE(actuals, predicts)
+
+	fmt.Printf("[cart_test] RMSE: %f, MAE: %f\n", rmse, mae)
+
+	// The error on training set must be less than predicting all samples
+	// with their mean.
+	varAll := variance.Compute(actuals)
+	if rmse*rmse >= varAll {
+		t.Fatalf("Expecting MSE less than %f, got %f", varAll,
+			rmse*rmse)
+	}
+}
+
+func checkGrowLimit(t *testing.T, node *binary.BTNode, depth, maxDepth,
+	minLeaf int,
+) {
+	nodev := node.Value.(cart.NodeValue)
+
+	if depth > maxDepth {
+		t.Fatalf("Expecting depth less or equal to %d, got %d",
+			maxDepth, depth)
+	}
+
+	if nodev.IsLeaf {
+		if nodev.Size < minLeaf {
+			t.Fatalf("Expecting leaf size at least %d, got %d",
+				minLeaf, nodev.Size)
+		}
+		return
+	}
+
+	checkGrowLimit(t, node.Left, depth+1, maxDepth, minLeaf)
+	checkGrowLimit(t, node.Right, depth+1, maxDepth, minLeaf)
+}
+
+func TestGrowLimit(t *testing.T) {
+	fds := "../../testdata/forensic_glass/fgl.dsv"
+
+	ds := tabula.Claset{}
+	_, e := dsv.SimpleRead(fds, &ds)
+	if nil != e {
+		t.Fatal(e)
+	}
+
+	CART := cart.Runtime{
+		SplitMethod:    cart.SplitMethodGini,
+		MaxDepth:       3,
+		MinSamplesLeaf: 5,
+	}
+
+	e = CART.Build(&ds)
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	checkGrowLimit(t, CART.Tree.Root, 0, CART.MaxDepth,
+		CART.MinSamplesLeaf)
+}
+
+func TestSplitMethod(t *testing.T) {
+	fds := "../../testdata/iris/iris.dsv"
+
+	for _, method := range []string{
+		cart.SplitMethodEntropy,
+		cart.SplitMethodGainRatio,
+	} {
+		ds := tabula.Claset{}
+		_, e := dsv.SimpleRead(fds, &ds)
+		if nil != e {
+			t.Fatal(e)
+		}
+
+		targetv := ds.GetClassAsStrings()
+
+		CART, e := cart.New(&ds, method, 0)
+		if e != nil {
+			t.Fatal(e)
+		}
+
+		testset := tabula.Claset{}
+		_, e = dsv.SimpleRead(fds, &testset)
+		if nil != e {
+			t.Fatal(e)
+		}
+
+		testset.GetClassColumn().ClearValues()
+
+		e = CART.ClassifySet(&testset)
+		if nil != e {
+			t.Fatal(e)
+		}
+
+		assert(t, targetv, testset.GetClassAsStrings(), true)
+	}
+}
+
+func TestClassifyProba(t *testing.T) {
+	fds := "../../testdata/forensic_glass/fgl.dsv"
+
+	ds := tabula.Claset{}
+	_, e := dsv.SimpleRead(fds, &ds)
+	if nil != e {
+		t.Fatal(e)
+	}
+
+	CART := cart.Runtime{
+		MaxDepth: 3,
+	}
+
+	e = CART.Build(&ds)
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	testset := tabula.Claset{}
+	_, e = dsv.SimpleRead(fds, &testset)
+	if nil != e {
+		t.Fatal(e)
+	}
+
+	rows := testset.GetRows()
+	for _, row := range *rows {
+		class := CART.Classify(row)
+		probs := CART.ClassifyProba(row)
+
+		sum := 0.0
+		for _, p := range probs {
+			sum += p
+		}
+		if sum < 0.999999 || sum > 1.000001 {
+			t.Fatalf("Expecting sum of probabilities 1, got %f", sum)
+		}
+
+		for c, p := range probs {
+			if p > probs[class] {
+				t.Fatalf("Expecting class %s with maximum"+
+					" probability, got %s", class, c)
+			}
+		}
+	}
+}
+
+//
+// hasParentReuse will return true if the tree has child node that is
+// splitted using the same attribute as their parent.
+//
+func hasParentReuse(node *binary.BTNode) bool {
+	nodev := node.Value.(cart.NodeValue)
+	if nodev.IsLeaf {
+		return false
+	}
+
+	for _, child := range []*binary.BTNode{node.Left, node.Right} {
+		childv := child.Value.(cart.NodeValue)
+		if !childv.IsLeaf && childv.SplitAttrIdx == nodev.SplitAttrIdx {
+			return true
+		}
+		if hasParentReuse(child) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func TestReuseContinuAttr(t *testing.T) {
+	// Dataset with only petal-length as feature, where separating all
+	// classes require splitting the same attribute twice.
+	fds := "../../testdata/iris/iris_petal.dsv"
+
+	for _, reuse := range []bool{false, true} {
+		ds := tabula.Claset{}
+		_, e := dsv.SimpleRead(fds, &ds)
+		if nil != e {
+			t.Fatal(e)
+		}
+
+		CART := cart.Runtime{
+			SplitMethod:      cart.SplitMethodGini,
+			ReuseContinuAttr: reuse,
+		}
+
+		e = CART.Build(&ds)
+		if e != nil {
+			t.Fatal(e)
+		}
+
+		fmt.Println("[cart_test] Tree with reuse", reuse, ":\n", &CART)
+
+		got := hasParentReuse(CART.Tree.Root)
+		if got != reuse {
+			t.Fatalf("Expecting parent attribute reused %v, got %v",
+				reuse, got)
+		}
+	}
+}
+
+func TestFeatureImportances(t *testing.T) {
+	fds := "../../testdata/iris/iris.dsv"
+
+	ds := tabula.Claset{}
+	_, e := dsv.SimpleRead(fds, &ds)
+	if nil != e {
+		t.Fatal(e)
+	}
+
+	CART, e := cart.New(&ds, cart.SplitMethodGini, 0)
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	imps := CART.FeatureImportances()
+
+	fmt.Println("[cart_test] feature importances:", imps)
+
+	if len(imps) == 0 {
+		t.Fatal("Expecting feature importances, got empty")
+	}
+
+	sum := 0.0
+	for x, imp := range imps {
+		if x > 0 && imp.Value > imps[x-1].Value {
+			t.Fatalf("Expecting ranked importances, got %v", imps)
+		}
+		sum += imp.Value
+	}
+	if sum < 0.999999 || sum > 1.000001 {
+		t.Fatalf("Expecting sum of importances 1, got %f", sum)
+	}
+
+	// The iris is mostly separated by petal length and width.
+	if imps[0].Name != "petal-length" && imps[0].Name != "petal-width" {
+		t.Fatalf("Expecting petal as the most important, got %s",
+			imps[0].Name)
+	}
+}
+
+func TestWriteDOT(t *testing.T) {
+	fds := "../../testdata/iris/iris.dsv"
+
+	ds := tabula.Claset{}
+	_, e := dsv.SimpleRead(fds, &ds)
+	if nil != e {
+		t.Fatal(e)
+	}
+
+	CART, e := cart.New(&ds, cart.SplitMethodGini, 0)
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	buf := bytes.Buffer{}
+
+	e = CART.WriteDOT(&buf)
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	dot := buf.String()
+	root := CART.Tree.Root.Value.(cart.NodeValue)
+
+	if !strings.HasPrefix(dot, "digraph \"cart\" {\n") {
+		t.Fatalf("Expecting DOT graph, got %s", dot)
+	}
+	if !strings.Contains(dot, root.SplitAttrName+" < ") {
+		t.Fatalf("Expecting root split on %s, got %s",
+			root.SplitAttrName, dot)
+	}
+}
+
+func TestRules(t *testing.T) {
+	fds := "../../testdata/iris/iris.dsv"
+
+	ds := tabula.Claset{}
+	_, e := dsv.SimpleRead(fds, &ds)
+	if nil != e {
+		t.Fatal(e)
+	}
+
+	CART := cart.Runtime{
+		SplitMethod:      cart.SplitMethodGini,
+		ReuseContinuAttr: true,
+	}
+
+	e = CART.Build(&ds)
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	rules := CART.Rules(false)
+	simples := CART.Rules(true)
+
+	assert(t, len(rules), len(simples), true)
+
+	coverage := 0
+	for x, rule := range simples {
+		fmt.Println("[cart_test] rule:", rule.String())
+
+		coverage += rule.Coverage
+
+		if len(rule.Conditions) > len(rules[x].Conditions) {
+			t.Fatalf("Expecting simplified rule, got %s",
+				rule.String())
+		}
+
+		// Each attribute and operator must appear only once.
+		seen := make(map[string]bool)
+		for _, cond := range rule.Conditions {
+			key := cond.AttrName + " " + cond.Op
+			if seen[key] {
+				t.Fatalf("Expecting merged condition %s in %s",
+					key, rule.String())
+			}
+			seen[key] = true
+		}
+	}
+
+	assert(t, NRows, coverage, true)
+}
+
+func TestExplain(t *testing.T) {
+	fds := "../../testdata/forensic_glass/fgl.dsv"
+
+	ds := tabula.Claset{}
+	_, e := dsv.SimpleRead(fds, &ds)
+	if nil != e {
+		t.Fatal(e)
+	}
+
+	CART := cart.Runtime{
+		MaxDepth: 4,
+	}
+
+	e = CART.Build(&ds)
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	testset := tabula.Claset{}
+	_, e = dsv.SimpleRead(fds, &testset)
+	if nil != e {
+		t.Fatal(e)
+	}
+
+	rows := testset.GetRows()
+	for x, row := range *rows {
+		exp := CART.Explain(row)
+
+		if x == 0 {
+			fmt.Println("[cart_test] explanation:\n", exp)
+		}
+
+		assert(t, CART.Classify(row), exp.Class, true)
+
+		// The bias plus all contributions must be equal to the
+		// probability of class in leaf.
+		got := exp.Bias
+		for _, contrib := range exp.Contributions {
+			got += contrib.Value
+		}
+
+		want := CART.ClassifyProba(row)[exp.Class]
+		if got < want-1e-9 || got > want+1e-9 {
+			t.Fatalf("Expecting probability %f, got %f", want, got)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+// Copyright 2016 Mhd Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cart
+
+import (
+	"fmt"
+	"github.com/shuLhan/go-mining/tree/binary"
+	"io"
+	"strings"
+)
+
+//
+// dotLabel return the label of node in DOT.
+// Internal node is labeled with the split condition, where sample that
+// satisfy the condition is sent to the left node. Leaf is labeled with the
+// class, or predicted value on regression tree.
+//
+func (runtime *Runtime) dotLabel(node *binary.BTNode) (label string) {
+	nodev := node.Value.(NodeValue)
+
+	if !nodev.IsLeaf {
+		if nodev.IsContinu {
+			label = fmt.Sprintf("%s < %v", nodev.SplitAttrName,
+				nodev.SplitV)
+		} else {
+			splitV, _ := nodev.SplitV.([]string)
+			label = fmt.Sprintf("%s in {%s}", nodev.SplitAttrName,
+				strings.Join(splitV, ", "))
+		}
+	} else if runtime.IsRegression() {
+		label = fmt.Sprintf("value = %v", nodev.Value)
+	} else {
+		label = fmt.Sprintf("class = %s", nodev.Class)
+	}
+
+	label += fmt.Sprintf("\nsize = %d", nodev.Size)
+
+	return label
+}
+
+/*
+WriteDOT will write the tree in Graphviz DOT language to `w`.
+Each internal node is labeled with split attribute, split value, and number of
+samples; and each leaf is labeled with class and number of samples.
+*/
+func (runtime *Runtime) WriteDOT(w io.Writer) error {
+	dot := &binary.DOT{
+		Name:       "cart",
+		LeftLabel:  "true",
+		RightLabel: "false",
+		Label:      runtime.dotLabel,
+	}
+
+	return dot.Write(w, &runtime.Tree)
+}
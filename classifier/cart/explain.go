@@ -0,0 +1,254 @@
+// Copyright 2016 Mhd Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cart
+
+import (
+	"fmt"
+	"github.com/shuLhan/go-mining/tree/binary"
+	"github.com/shuLhan/tabula"
+	"math"
+	"sort"
+)
+
+/*
+Step define one internal node that is visited when classifying a sample.
+*/
+type Step struct {
+	// AttrIdx define the index of split attribute in node.
+	AttrIdx int
+	// AttrName define the name of split attribute in node.
+	AttrName string
+	// IsContinu define whether the split attribute is continuous or
+	// discrete.
+	IsContinu bool
+	// SplitV define the split value in node.
+	SplitV interface{}
+	// AttrValue define the value of split attribute in sample.
+	AttrValue string
+	// IsMissing is true if the value of split attribute in sample is
+	// missing, and the direction is taken from surrogate or from majority
+	// direction in node.
+	IsMissing bool
+	// IsLeft is true if sample is sent to the left node.
+	IsLeft bool
+	// Size define number of training samples in node.
+	Size int
+}
+
+/*
+String will return the step in the form of "attr = value, op split, go to
+direction (size: N)".
+*/
+func (step *Step) String() string {
+	dir := "right"
+	if step.IsLeft {
+		dir = "left"
+	}
+
+	value := step.AttrValue
+	if step.IsMissing {
+		value = "?"
+	}
+
+	var op string
+	if step.IsContinu {
+		op = OpGreaterEqual
+		if step.IsLeft {
+			op = OpLess
+		}
+	} else {
+		op = OpNotIn
+		if step.IsLeft {
+			op = OpIn
+		}
+	}
+
+	return fmt.Sprintf("%s = %s, %s %v, go %s (size: %d)", step.AttrName,
+		value, op, step.SplitV, dir, step.Size)
+}
+
+/*
+Contribution define the contribution of attribute to the prediction.
+*/
+type Contribution struct {
+	// AttrIdx define the index of attribute.
+	AttrIdx int
+	// AttrName define the name of attribute.
+	AttrName string
+	// Value define the change of predicted probability, or predicted
+	// value in regression tree, caused by the attribute.
+	Value float64
+}
+
+//
+// Contributions define list of contribution, which can be sorted by their
+// absolute value in descending order.
+//
+type Contributions []Contribution
+
+func (contribs Contributions) Len() int {
+	return len(contribs)
+}
+
+func (contribs Contributions) Less(i, j int) bool {
+	return math.Abs(contribs[i].Value) > math.Abs(contribs[j].Value)
+}
+
+func (contribs Contributions) Swap(i, j int) {
+	contribs[i], contribs[j] = contribs[j], contribs[i]
+}
+
+/*
+Explanation contain the path of sample from root to leaf, and the contribution
+of each attribute in path to the prediction.
+
+The contribution is computed using the method by Saabas, where the prediction
+is decomposed into,
+
+	prediction = Bias + sum(contribution of each attribute)
+
+Bias is the probability of class, or mean value on regression tree, in the
+root node, and each split add the difference between the probability of class
+in the child node and in the node to the split attribute.
+*/
+type Explanation struct {
+	// Path contain all internal nodes visited from root to leaf.
+	Path []Step
+	// Class define the class of leaf.
+	Class string
+	// Value define the predicted value on regression tree.
+	Value float64
+	// Size define number of training samples in leaf.
+	Size int
+	// Target define the class that is explained by contributions.
+	Target string
+	// Bias define the probability of Target in root node, or the mean
+	// value in root on regression tree.
+	Bias float64
+	// Contributions contain the contribution of each attribute in path,
+	// ordered by their absolute value.
+	Contributions Contributions
+}
+
+/*
+String will return the path and contributions as multiple lines text.
+*/
+func (exp *Explanation) String() (s string) {
+	for x := range exp.Path {
+		s += fmt.Sprintln(exp.Path[x].String())
+	}
+	s += fmt.Sprintf("class = %s (size: %d)\n", exp.Class, exp.Size)
+	s += fmt.Sprintf("bias (%s) = %v\n", exp.Target, exp.Bias)
+	for _, contrib := range exp.Contributions {
+		s += fmt.Sprintf("%s: %v\n", contrib.AttrName, contrib.Value)
+	}
+	return s
+}
+
+//
+// nodeScore return the probability of `class` in node, or the predicted
+// value on regression tree.
+//
+func (runtime *Runtime) nodeScore(nodev *NodeValue, class string) float64 {
+	if runtime.IsRegression() {
+		return nodev.Value
+	}
+	return nodev.Proba()[class]
+}
+
+/*
+Explain return the path of sample `data` from root to leaf and the
+contribution of each attribute to the predicted class.
+*/
+func (runtime *Runtime) Explain(data *tabula.Row) *Explanation {
+	return runtime.ExplainClass(data, "")
+}
+
+/*
+ExplainClass return the path of sample `data` from root to leaf and the
+contribution of each attribute to the probability of `class`.
+If `class` is empty, the predicted class is used.
+*/
+func (runtime *Runtime) ExplainClass(data *tabula.Row, class string) (
+	exp *Explanation,
+) {
+	exp = &Explanation{}
+
+	node := runtime.Tree.Root
+	if node == nil {
+		return exp
+	}
+
+	nodes := []*binary.BTNode{node}
+	nodev := node.Value.(NodeValue)
+
+	for !nodev.IsLeaf {
+		rec := (*data)[nodev.SplitAttrIdx]
+
+		step := Step{
+			AttrIdx:   nodev.SplitAttrIdx,
+			AttrName:  nodev.SplitAttrName,
+			IsContinu: nodev.IsContinu,
+			SplitV:    nodev.SplitV,
+			IsMissing: isMissing(rec, nodev.IsContinu),
+			IsLeft:    nodev.isLeft(data),
+			Size:      nodev.Size,
+		}
+		if rec != nil {
+			step.AttrValue = rec.String()
+		}
+
+		exp.Path = append(exp.Path, step)
+
+		if step.IsLeft {
+			node = node.Left
+		} else {
+			node = node.Right
+		}
+
+		nodes = append(nodes, node)
+		nodev = node.Value.(NodeValue)
+	}
+
+	exp.Class = nodev.Class
+	exp.Value = nodev.Value
+	exp.Size = nodev.Size
+
+	if class == "" {
+		class = exp.Class
+	}
+	exp.Target = class
+
+	// Compute the contribution of each attribute in path.
+	values := make(map[int]float64)
+
+	rootv := nodes[0].Value.(NodeValue)
+	prev := runtime.nodeScore(&rootv, class)
+	exp.Bias = prev
+
+	for x, step := range exp.Path {
+		childv := nodes[x+1].Value.(NodeValue)
+		score := runtime.nodeScore(&childv, class)
+
+		if _, ok := values[step.AttrIdx]; !ok {
+			exp.Contributions = append(exp.Contributions,
+				Contribution{
+					AttrIdx:  step.AttrIdx,
+					AttrName: step.AttrName,
+				})
+		}
+		values[step.AttrIdx] += score - prev
+
+		prev = score
+	}
+
+	for x := range exp.Contributions {
+		exp.Contributions[x].Value = values[exp.Contributions[x].AttrIdx]
+	}
+
+	sort.Stable(exp.Contributions)
+
+	return exp
+}
@@ -0,0 +1,69 @@
+// Copyright 2016 Mhd Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cart
+
+import (
+	"github.com/shuLhan/go-mining/classifier"
+	"github.com/shuLhan/go-mining/tree/binary"
+)
+
+//
+// FeatureImportances return the mean decrease of impurity for each feature
+// that is used to split the tree, ordered from the most important.
+//
+// The importance of feature is the sum of weighted decrease of impurity,
+//
+//	(number of samples in node / number of samples in root) * gain
+//
+// in all nodes that split on the feature, normalized so the total
+// importance is one.
+//
+func (runtime *Runtime) FeatureImportances() (imps classifier.Importances) {
+	root := runtime.Tree.Root
+	if root == nil {
+		return nil
+	}
+
+	total := float64(root.Value.(NodeValue).Size)
+	if total <= 0 {
+		return nil
+	}
+
+	values := make(map[int]float64)
+	names := make(map[int]string)
+
+	root.WalkPreOrder(func(node *binary.BTNode) bool {
+		nodev := node.Value.(NodeValue)
+		if nodev.IsLeaf {
+			return true
+		}
+
+		values[nodev.SplitAttrIdx] += float64(nodev.Size) / total *
+			nodev.Gain
+		names[nodev.SplitAttrIdx] = nodev.SplitAttrName
+
+		return true
+	})
+
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+
+	for idx, v := range values {
+		if sum > 0 {
+			v /= sum
+		}
+		imps = append(imps, classifier.Importance{
+			Index: idx,
+			Name:  names[idx],
+			Value: v,
+		})
+	}
+
+	imps.Rank()
+
+	return imps
+}
@@ -0,0 +1,89 @@
+// Copyright 2016 Mhd Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cart_test
+
+import (
+	"fmt"
+	"github.com/shuLhan/dsv"
+	"github.com/shuLhan/go-mining/classifier/cart"
+	"github.com/shuLhan/go-mining/tree/binary"
+	"github.com/shuLhan/tabula"
+	"math"
+	"testing"
+)
+
+//
+// classEntropy return the entropy of class in node.
+//
+func classEntropy(nodev cart.NodeValue) (v float64) {
+	for _, n := range nodev.ClassCount {
+		if n == 0 {
+			continue
+		}
+		p := float64(n) / float64(nodev.Size)
+		v -= p * math.Log2(p)
+	}
+	return v
+}
+
+func TestFeatureImportancesGainRatio(t *testing.T) {
+	fds := "../../testdata/iris/iris.dsv"
+
+	ds := tabula.Claset{}
+	_, e := dsv.SimpleRead(fds, &ds)
+	if nil != e {
+		t.Fatal(e)
+	}
+
+	CART, e := cart.New(&ds, cart.SplitMethodGainRatio, 0)
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	// The gain in each node must be the information gain of the split,
+	// not the gain ratio that is used to select the attribute.
+	CART.Tree.Root.WalkPreOrder(func(node *binary.BTNode) bool {
+		nodev := node.Value.(cart.NodeValue)
+		if nodev.IsLeaf {
+			return true
+		}
+
+		left := node.Left.Value.(cart.NodeValue)
+		right := node.Right.Value.(cart.NodeValue)
+		n := float64(nodev.Size)
+
+		exp := classEntropy(nodev) -
+			float64(left.Size)/n*classEntropy(left) -
+			float64(right.Size)/n*classEntropy(right)
+
+		if math.Abs(exp-nodev.Gain) > 1e-9 {
+			t.Fatalf("Expecting information gain %f on %s, got %f",
+				exp, nodev.SplitAttrName, nodev.Gain)
+		}
+
+		return true
+	})
+
+	imps := CART.FeatureImportances()
+
+	fmt.Println("[cart_test] gain ratio feature importances:", imps)
+
+	if len(imps) == 0 {
+		t.Fatal("Expecting feature importances, got empty")
+	}
+
+	sum := 0.0
+	for _, imp := range imps {
+		sum += imp.Value
+	}
+	if sum < 0.999999 || sum > 1.000001 {
+		t.Fatalf("Expecting sum of importances 1, got %f", sum)
+	}
+
+	if imps[0].Name != "petal-length" && imps[0].Name != "petal-width" {
+		t.Fatalf("Expecting petal as the most important, got %s",
+			imps[0].Name)
+	}
+}
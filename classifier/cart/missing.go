@@ -0,0 +1,364 @@
+// Copyright 2016 Mhd Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cart
+
+import (
+	"fmt"
+	"github.com/shuLhan/numerus"
+	"github.com/shuLhan/tabula"
+	"github.com/shuLhan/tekstus"
+	"math"
+	"sort"
+)
+
+const (
+	// MaxSurrogate define the maximum number of surrogate splits saved in
+	// each node.
+	MaxSurrogate = 5
+)
+
+//
+// isMissing will return true if record is empty, or NaN on continuous
+// attribute.
+//
+func isMissing(rec *tabula.Record, isContinu bool) bool {
+	if rec == nil {
+		return true
+	}
+	if isContinu {
+		return math.IsNaN(rec.Float())
+	}
+	return rec.String() == ""
+}
+
+//
+// isLeftBy will return true if record `rec` is sent to the left node by
+// split value `splitV`.
+//
+func isLeftBy(isContinu bool, splitV interface{}, rec *tabula.Record) bool {
+	if isContinu {
+		return rec.Float() < splitV.(float64)
+	}
+	return tekstus.StringsIsContain(splitV.([]string), rec.String())
+}
+
+//
+// knownIndex return index of records which value is not missing, and true if
+// at least one of the records is missing.
+//
+func knownIndex(records tabula.Records, isContinu bool) (
+	known []int, hasMiss bool,
+) {
+	for x, rec := range records {
+		if isMissing(rec, isContinu) {
+			hasMiss = true
+			continue
+		}
+		known = append(known, x)
+	}
+	return
+}
+
+//
+// selectFloats return values in `v` at index `idx`.
+//
+func selectFloats(v []float64, idx []int) (sub []float64) {
+	sub = make([]float64, len(idx))
+	for x, y := range idx {
+		sub[x] = v[y]
+	}
+	return
+}
+
+//
+// selectStrings return values in `v` at index `idx`.
+//
+func selectStrings(v []string, idx []int) (sub []string) {
+	sub = make([]string, len(idx))
+	for x, y := range idx {
+		sub[x] = v[y]
+	}
+	return
+}
+
+//
+// knownValues return the value space without empty value.
+//
+func knownValues(vs []string) (known []string) {
+	for _, v := range vs {
+		if v != "" {
+			known = append(known, v)
+		}
+	}
+	return
+}
+
+//
+// splitNode will split dataset `D` using split attribute and value in
+// `nodev`.
+//
+// Before splitting, the surrogate splits and the majority direction is
+// computed and saved in `nodev`. Sample with missing value on split attribute
+// is sent using the surrogates, or using the majority direction if all
+// surrogate attributes is also missing.
+//
+func (runtime *Runtime) splitNode(D tabula.ClasetInterface,
+	nodev *NodeValue,
+) (
+	splitL, splitR tabula.ClasetInterface, e error,
+) {
+	rows := D.GetRows()
+	nrow := len(*rows)
+
+	goLeft := make([]bool, nrow)
+	known := make([]bool, nrow)
+	hasMiss := false
+	nknown := 0
+	nleft := 0
+
+	for x, row := range *rows {
+		rec := (*row)[nodev.SplitAttrIdx]
+
+		if isMissing(rec, nodev.IsContinu) {
+			hasMiss = true
+			continue
+		}
+
+		known[x] = true
+		nknown++
+
+		goLeft[x] = isLeftBy(nodev.IsContinu, nodev.SplitV, rec)
+		if goLeft[x] {
+			nleft++
+		}
+	}
+
+	nodev.DefaultLeft = nleft*2 > nknown
+	nodev.Surrogates = findSurrogates(D, nodev.SplitAttrIdx, goLeft,
+		known)
+
+	if DEBUG >= 2 {
+		fmt.Println("[cart] surrogates:", nodev.Surrogates)
+	}
+
+	if !hasMiss {
+		return splitByAttr(D, nodev.SplitAttrIdx, nodev.SplitV)
+	}
+
+	for x, row := range *rows {
+		if !known[x] {
+			goLeft[x] = nodev.isLeft(row)
+		}
+	}
+
+	splitL, splitR = splitByDirection(D, nodev.SplitAttrIdx, goLeft)
+
+	return splitL, splitR, nil
+}
+
+//
+// splitByDirection will split the dataset into two subset, where the left
+// subset contain all rows which `goLeft` is true.
+//
+func splitByDirection(D tabula.ClasetInterface, attrIdx int, goLeft []bool) (
+	splitL, splitR tabula.ClasetInterface,
+) {
+	goRight := make([]bool, len(goLeft))
+	for x := range goLeft {
+		goRight[x] = !goLeft[x]
+	}
+
+	splitL = selectRows(D, goLeft)
+	splitR = selectRows(D, goRight)
+
+	setParentFlag(splitL, attrIdx)
+	setParentFlag(splitR, attrIdx)
+
+	return splitL, splitR
+}
+
+//
+// bySurrogateAgreement sort the surrogates by their agreement, in descending
+// order.
+//
+type bySurrogateAgreement []Surrogate
+
+func (surs bySurrogateAgreement) Len() int {
+	return len(surs)
+}
+
+func (surs bySurrogateAgreement) Less(i, j int) bool {
+	return surs[i].Agreement > surs[j].Agreement
+}
+
+func (surs bySurrogateAgreement) Swap(i, j int) {
+	surs[i], surs[j] = surs[j], surs[i]
+}
+
+//
+// findSurrogates will find the best split on each attribute, other than the
+// split attribute `splitIdx`, which mimic the direction of primary split in
+// `goLeft`. Only rows which value of split attribute is `known` is used.
+//
+// Surrogate is accepted only if its send more samples to the same node as
+// the primary split than sending all samples to the majority direction.
+//
+func findSurrogates(D tabula.ClasetInterface, splitIdx int,
+	goLeft, known []bool,
+) (
+	surs []Surrogate,
+) {
+	classIdx := D.GetClassIndex()
+
+	for x, col := range *D.GetColumns() {
+		if x == classIdx || x == splitIdx {
+			continue
+		}
+		if (col.Flag & ColFlagSkip) == ColFlagSkip {
+			continue
+		}
+
+		isContinu := col.GetType() == tabula.TReal
+
+		var idx []int
+		for y, rec := range col.Records {
+			if known[y] && !isMissing(rec, isContinu) {
+				idx = append(idx, y)
+			}
+		}
+		if len(idx) == 0 {
+			continue
+		}
+
+		nleft := 0
+		for _, y := range idx {
+			if goLeft[y] {
+				nleft++
+			}
+		}
+
+		nmajor := nleft
+		if len(idx)-nleft > nmajor {
+			nmajor = len(idx) - nleft
+		}
+
+		var sur Surrogate
+		var agree int
+
+		if isContinu {
+			sur, agree = findContinuSurrogate(col.ToFloatSlice(),
+				idx, goLeft)
+		} else {
+			sur, agree = findDiscreteSurrogate(col.ToStringSlice(),
+				idx, goLeft)
+		}
+
+		if agree <= nmajor {
+			continue
+		}
+
+		sur.AttrIdx = x
+		sur.AttrName = col.GetName()
+		sur.IsContinu = isContinu
+		sur.Agreement = float64(agree) / float64(len(idx))
+
+		surs = append(surs, sur)
+	}
+
+	sort.Stable(bySurrogateAgreement(surs))
+
+	if len(surs) > MaxSurrogate {
+		surs = surs[:MaxSurrogate]
+	}
+
+	return surs
+}
+
+//
+// findContinuSurrogate return the split on continuous attribute `values`
+// which have the maximum number of samples that agree with `goLeft`.
+// Only value at index `idx` is used.
+//
+func findContinuSurrogate(values []float64, idx []int, goLeft []bool) (
+	sur Surrogate, agree int,
+) {
+	n := len(idx)
+	A := selectFloats(values, idx)
+
+	sorted := numerus.Floats64IndirectSort(A, true)
+
+	nleft := 0
+	for _, y := range idx {
+		if goLeft[y] {
+			nleft++
+		}
+	}
+
+	cumLeft := 0
+	for x := 0; x < n-1; x++ {
+		if goLeft[idx[sorted[x]]] {
+			cumLeft++
+		}
+
+		if A[x] == A[x+1] {
+			continue
+		}
+
+		// number of samples that agree if all samples less than
+		// split value is sent to the left.
+		normal := cumLeft + (n - x - 1) - (nleft - cumLeft)
+		reverse := n - normal
+
+		if normal > agree {
+			agree = normal
+			sur.SplitContinu = (A[x] + A[x+1]) / 2
+			sur.Reverse = false
+		}
+		if reverse > agree {
+			agree = reverse
+			sur.SplitContinu = (A[x] + A[x+1]) / 2
+			sur.Reverse = true
+		}
+	}
+
+	return sur, agree
+}
+
+//
+// findDiscreteSurrogate return the split on discrete attribute `values`
+// which have the maximum number of samples that agree with `goLeft`, where
+// each value is sent to the direction of most of their samples.
+// Only value at index `idx` is used.
+//
+func findDiscreteSurrogate(values []string, idx []int, goLeft []bool) (
+	sur Surrogate, agree int,
+) {
+	var vs []string
+	nleft := make(map[string]int)
+	nright := make(map[string]int)
+
+	for _, y := range idx {
+		v := values[y]
+		if nleft[v] == 0 && nright[v] == 0 {
+			vs = append(vs, v)
+		}
+		if goLeft[y] {
+			nleft[v]++
+		} else {
+			nright[v]++
+		}
+	}
+
+	for _, v := range vs {
+		if nleft[v] > nright[v] {
+			sur.SplitDiscrete = append(sur.SplitDiscrete, v)
+			agree += nleft[v]
+		} else {
+			agree += nright[v]
+		}
+	}
+
+	return sur, agree
+}
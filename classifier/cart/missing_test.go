@@ -0,0 +1,82 @@
+// Copyright 2016 Mhd Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cart_test
+
+import (
+	"fmt"
+	"github.com/shuLhan/dsv"
+	"github.com/shuLhan/go-mining/classifier/cart"
+	"github.com/shuLhan/tabula"
+	"math"
+	"testing"
+)
+
+func TestMissingValue(t *testing.T) {
+	fds := "../../testdata/iris/iris.dsv"
+
+	ds := tabula.Claset{}
+	_, e := dsv.SimpleRead(fds, &ds)
+	if nil != e {
+		t.Fatal(e)
+	}
+
+	// Remove the petal-length value in every third samples.
+	for x := 0; x < ds.GetNRow(); x += 3 {
+		(*ds.GetRow(x))[2].SetFloat(math.NaN())
+	}
+
+	CART, e := cart.New(&ds, cart.SplitMethodGini, 0)
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	root := CART.Tree.Root.Value.(cart.NodeValue)
+	if len(root.Surrogates) == 0 {
+		t.Fatal("Expecting surrogates in root node, got empty")
+	}
+
+	fmt.Println("[cart_test] root surrogates:", root.Surrogates)
+
+	// Samples with missing value must be sent to one of the child, not
+	// dropped.
+	left := CART.Tree.Root.Left.Value.(cart.NodeValue)
+	right := CART.Tree.Root.Right.Value.(cart.NodeValue)
+	if left.Size == 0 || right.Size == 0 {
+		t.Fatalf("Expecting non-empty children, got left %d right %d",
+			left.Size, right.Size)
+	}
+
+	assert(t, root.Size, left.Size+right.Size, true)
+
+	testset := tabula.Claset{}
+	_, e = dsv.SimpleRead(fds, &testset)
+	if nil != e {
+		t.Fatal(e)
+	}
+
+	targetv := testset.GetClassAsStrings()
+
+	// Remove all petal values, so classification must use surrogates.
+	rows := testset.GetRows()
+	for _, row := range *rows {
+		(*row)[2].SetFloat(math.NaN())
+		(*row)[3].SetFloat(math.NaN())
+	}
+
+	ntrue := 0
+	for x, row := range *rows {
+		if CART.Classify(row) == targetv[x] {
+			ntrue++
+		}
+	}
+
+	fmt.Printf("[cart_test] true positive with missing values: %d/%d\n",
+		ntrue, len(targetv))
+
+	if ntrue*2 < len(targetv) {
+		t.Fatalf("Expecting at least half samples classified correctly,"+
+			" got %d/%d", ntrue, len(targetv))
+	}
+}
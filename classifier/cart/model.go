@@ -0,0 +1,217 @@
+// Copyright 2016 Mhd Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cart
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/shuLhan/go-mining/tree/binary"
+	"io"
+)
+
+const (
+	// ModelVersion define the version of tree format written by Save.
+	ModelVersion = 1
+)
+
+var (
+	// ErrModelVersion will be returned by Load when the version of saved
+	// tree is unknown.
+	ErrModelVersion = errors.New("cart: unknown model version")
+	// ErrModelSplitValue will be returned by Load when the split value in
+	// internal node is missing.
+	ErrModelSplitValue = errors.New("cart: invalid split value in model")
+	// ErrModelRoot will be returned by Load when the saved tree does not
+	// have root node.
+	ErrModelRoot = errors.New("cart: missing root node in model")
+)
+
+//
+// nodeModel define the serialized form of tree node.
+// The split value is saved on SplitContinu if the node is continuous,
+// otherwise it's saved on SplitDiscrete.
+//
+type nodeModel struct {
+	Class         string         `json:"Class,omitempty"`
+	SplitAttrName string         `json:"SplitAttrName,omitempty"`
+	IsLeaf        bool           `json:"IsLeaf"`
+	IsContinu     bool           `json:"IsContinu,omitempty"`
+	Size          int            `json:"Size"`
+	Miss          int            `json:"Miss,omitempty"`
+	SplitAttrIdx  int            `json:"SplitAttrIdx,omitempty"`
+	SplitContinu  float64        `json:"SplitContinu,omitempty"`
+	SplitDiscrete []string       `json:"SplitDiscrete,omitempty"`
+	Value         float64        `json:"Value,omitempty"`
+	Surrogates    []Surrogate    `json:"Surrogates,omitempty"`
+	DefaultLeft   bool           `json:"DefaultLeft,omitempty"`
+	ClassCount    map[string]int `json:"ClassCount,omitempty"`
+	Gain          float64        `json:"Gain,omitempty"`
+	Left          *nodeModel     `json:"Left,omitempty"`
+	Right         *nodeModel     `json:"Right,omitempty"`
+}
+
+//
+// model define the serialized form of Runtime.
+//
+type model struct {
+	Version        int        `json:"Version"`
+	SplitMethod    string     `json:"SplitMethod"`
+	NRandomFeature int        `json:"NRandomFeature"`
+	LeafPredict    string     `json:"LeafPredict,omitempty"`
+	OOBErrVal      float64    `json:"OOBErrVal"`
+	Root           *nodeModel `json:"Root"`
+}
+
+//
+// newNodeModel convert the tree node and all of its children to model.
+//
+func newNodeModel(node *binary.BTNode) (nm *nodeModel) {
+	if node == nil {
+		return nil
+	}
+
+	nodev := node.Value.(NodeValue)
+
+	nm = &nodeModel{
+		Class:         nodev.Class,
+		SplitAttrName: nodev.SplitAttrName,
+		IsLeaf:        nodev.IsLeaf,
+		IsContinu:     nodev.IsContinu,
+		Size:          nodev.Size,
+		Miss:          nodev.Miss,
+		SplitAttrIdx:  nodev.SplitAttrIdx,
+		Value:         nodev.Value,
+		Surrogates:    nodev.Surrogates,
+		DefaultLeft:   nodev.DefaultLeft,
+		ClassCount:    nodev.ClassCount,
+		Gain:          nodev.Gain,
+	}
+
+	if !nodev.IsLeaf {
+		if nodev.IsContinu {
+			nm.SplitContinu = nodev.SplitV.(float64)
+		} else {
+			nm.SplitDiscrete = nodev.SplitV.([]string)
+		}
+	}
+
+	nm.Left = newNodeModel(node.Left)
+	nm.Right = newNodeModel(node.Right)
+
+	return nm
+}
+
+//
+// toBTNode convert the model back to tree node, including all of its
+// children.
+//
+func (nm *nodeModel) toBTNode() (node *binary.BTNode, e error) {
+	if nm == nil {
+		return nil, nil
+	}
+
+	nodev := NodeValue{
+		Class:         nm.Class,
+		SplitAttrName: nm.SplitAttrName,
+		IsLeaf:        nm.IsLeaf,
+		IsContinu:     nm.IsContinu,
+		Size:          nm.Size,
+		Miss:          nm.Miss,
+		SplitAttrIdx:  nm.SplitAttrIdx,
+		Value:         nm.Value,
+		Surrogates:    nm.Surrogates,
+		DefaultLeft:   nm.DefaultLeft,
+		ClassCount:    nm.ClassCount,
+		Gain:          nm.Gain,
+	}
+
+	if !nm.IsLeaf {
+		if nm.IsContinu {
+			nodev.SplitV = nm.SplitContinu
+		} else {
+			if nm.SplitDiscrete == nil {
+				return nil, ErrModelSplitValue
+			}
+			nodev.SplitV = nm.SplitDiscrete
+		}
+	}
+
+	node = &binary.BTNode{
+		Value: nodev,
+	}
+
+	left, e := nm.Left.toBTNode()
+	if e != nil {
+		return nil, e
+	}
+	right, e := nm.Right.toBTNode()
+	if e != nil {
+		return nil, e
+	}
+
+	if !nm.IsLeaf && (left == nil || right == nil) {
+		return nil, fmt.Errorf("cart: internal node %q without child",
+			nm.SplitAttrName)
+	}
+
+	if left != nil {
+		node.SetLeft(left)
+	}
+	if right != nil {
+		node.SetRight(right)
+	}
+
+	return node, nil
+}
+
+//
+// Save will write the tree and its configuration to `w` in JSON format.
+//
+func (runtime *Runtime) Save(w io.Writer) error {
+	m := model{
+		Version:        ModelVersion,
+		SplitMethod:    runtime.SplitMethod,
+		NRandomFeature: runtime.NRandomFeature,
+		LeafPredict:    runtime.LeafPredict,
+		OOBErrVal:      runtime.OOBErrVal,
+		Root:           newNodeModel(runtime.Tree.Root),
+	}
+
+	return json.NewEncoder(w).Encode(&m)
+}
+
+//
+// Load will read the tree and its configuration, that has been written by
+// Save, from `r`. Any tree in runtime will be replaced.
+//
+func (runtime *Runtime) Load(r io.Reader) (e error) {
+	m := model{}
+
+	e = json.NewDecoder(r).Decode(&m)
+	if e != nil {
+		return e
+	}
+
+	if m.Version <= 0 || m.Version > ModelVersion {
+		return ErrModelVersion
+	}
+	if m.Root == nil {
+		return ErrModelRoot
+	}
+
+	root, e := m.Root.toBTNode()
+	if e != nil {
+		return e
+	}
+
+	runtime.SplitMethod = m.SplitMethod
+	runtime.NRandomFeature = m.NRandomFeature
+	runtime.LeafPredict = m.LeafPredict
+	runtime.OOBErrVal = m.OOBErrVal
+	runtime.Tree.Root = root
+
+	return nil
+}
@@ -6,14 +6,42 @@ package cart
 
 import (
 	"fmt"
+	"github.com/shuLhan/tabula"
+	"github.com/shuLhan/tekstus"
 	"reflect"
 )
 
+/*
+Surrogate define the split on another attribute which mimic the primary split
+in node. Surrogate is used when the value of primary split attribute is
+missing.
+*/
+type Surrogate struct {
+	// AttrIdx define the index of surrogate attribute.
+	AttrIdx int `json:"AttrIdx"`
+	// AttrName define the name of surrogate attribute.
+	AttrName string `json:"AttrName,omitempty"`
+	// IsContinu define whether the surrogate attribute is continuous or
+	// discrete.
+	IsContinu bool `json:"IsContinu,omitempty"`
+	// SplitContinu define the split value on continuous attribute.
+	SplitContinu float64 `json:"SplitContinu,omitempty"`
+	// SplitDiscrete define the split values on discrete attribute.
+	SplitDiscrete []string `json:"SplitDiscrete,omitempty"`
+	// Reverse if its true, the sample with continuous value less than
+	// SplitContinu is sent to the right node instead of the left.
+	Reverse bool `json:"Reverse,omitempty"`
+	// Agreement define the ratio of samples which is sent to the same
+	// node as the primary split.
+	Agreement float64 `json:"Agreement"`
+}
+
 /*
 NodeValue of tree in CART.
 */
 type NodeValue struct {
-	// Class of leaf node.
+	// Class of leaf node. On internal node, this is the majority class of
+	// samples before splitting.
 	Class string
 	// SplitAttrName define the name of attribute which cause the split.
 	SplitAttrName string
@@ -23,10 +51,53 @@ type NodeValue struct {
 	IsContinu bool
 	// Size define number of sample that this node hold before splitting.
 	Size int
+	// Miss define number of sample in node which class is not equal to
+	// Class.
+	Miss int
 	// SplitAttrIdx define the attribute which cause the split.
 	SplitAttrIdx int
 	// SplitV define the split value.
 	SplitV interface{}
+	// Value define the predicted value in regression tree.
+	Value float64
+	// Gain define the decrease of impurity, or variance in regression
+	// tree, when node is splitted.
+	Gain float64
+	// ClassCount define number of samples for each class in node before
+	// splitting.
+	ClassCount map[string]int
+	// Surrogates contain list of surrogate split, ordered by their
+	// agreement with the primary split.
+	Surrogates []Surrogate
+	// DefaultLeft define the direction of sample when the value of
+	// primary split attribute and all surrogate attributes is missing.
+	// Its true if most of samples is sent to the left node.
+	DefaultLeft bool
+}
+
+/*
+Proba return the probability of each class in node.
+If node does not have the number of samples for each class, the probability
+of Class is set to one.
+*/
+func (nodev *NodeValue) Proba() (probs map[string]float64) {
+	probs = make(map[string]float64)
+
+	total := 0
+	for _, n := range nodev.ClassCount {
+		total += n
+	}
+
+	if total <= 0 {
+		probs[nodev.Class] = 1
+		return probs
+	}
+
+	for class, n := range nodev.ClassCount {
+		probs[class] = float64(n) / float64(total)
+	}
+
+	return probs
 }
 
 /*
@@ -42,3 +113,46 @@ func (nodev *NodeValue) String() (s string) {
 
 	return s
 }
+
+//
+// isLeft will return true if record `rec` on surrogate attribute is sent to
+// the left node.
+//
+func (sur *Surrogate) isLeft(rec *tabula.Record) bool {
+	if sur.IsContinu {
+		left := rec.Float() < sur.SplitContinu
+		if sur.Reverse {
+			return !left
+		}
+		return left
+	}
+
+	return tekstus.StringsIsContain(sur.SplitDiscrete, rec.String())
+}
+
+//
+// isLeft will return true if sample `row` is sent to the left node.
+// If the value of split attribute in sample is missing, the direction is
+// taken from the first surrogate which value is not missing, or from
+// DefaultLeft.
+//
+func (nodev *NodeValue) isLeft(row *tabula.Row) bool {
+	rec := (*row)[nodev.SplitAttrIdx]
+
+	if !isMissing(rec, nodev.IsContinu) {
+		return isLeftBy(nodev.IsContinu, nodev.SplitV, rec)
+	}
+
+	for x := range nodev.Surrogates {
+		sur := &nodev.Surrogates[x]
+
+		rec = (*row)[sur.AttrIdx]
+		if isMissing(rec, sur.IsContinu) {
+			continue
+		}
+
+		return sur.isLeft(rec)
+	}
+
+	return nodev.DefaultLeft
+}
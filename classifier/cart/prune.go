@@ -0,0 +1,498 @@
+// Copyright 2016 Mhd Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cart
+
+import (
+	"errors"
+	"fmt"
+	"github.com/shuLhan/go-mining/tree/binary"
+	"github.com/shuLhan/tabula"
+	"math"
+	"math/rand"
+)
+
+var (
+	// ErrPruneNoSample will be returned when the samples for pruning is
+	// too small.
+	ErrPruneNoSample = errors.New("cart: not enough samples for pruning")
+)
+
+//
+// PruneStep contain one subtree in the sequence of minimal cost-complexity
+// pruning.
+//
+type PruneStep struct {
+	// Alpha is the complexity parameter where this subtree become the
+	// minimal cost-complexity tree.
+	Alpha float64
+	// NLeaf is number of leaf in subtree.
+	NLeaf int
+	// Tree is the pruned tree.
+	Tree binary.Tree
+}
+
+//
+// cloneNode return a deep copy of node and all of its children.
+//
+func cloneNode(node *binary.BTNode) *binary.BTNode {
+	if node == nil {
+		return nil
+	}
+
+	return binary.NewBTNode(node.Value, cloneNode(node.Left),
+		cloneNode(node.Right))
+}
+
+//
+// collapse will convert the internal node into leaf, labeled with majority
+// class of the node.
+//
+func collapse(node *binary.BTNode) {
+	nodev := node.Value.(NodeValue)
+
+	nodev.IsLeaf = true
+	nodev.IsContinu = false
+	nodev.SplitAttrName = ""
+	nodev.SplitAttrIdx = 0
+	nodev.SplitV = nil
+	nodev.Surrogates = nil
+	nodev.DefaultLeft = false
+	nodev.Gain = 0
+
+	node.Value = nodev
+	node.Left = nil
+	node.Right = nil
+}
+
+//
+// findWeakestLinks will find all internal nodes which have the minimum
+// cost-complexity,
+//
+//	g(t) = (R(t) - R(T_t)) / (|T_t| - 1)
+//
+// where R(t) is the resubstitution error of node `t` if its become a leaf,
+// R(T_t) is the resubstitution error of all leaves in subtree `t`, and |T_t|
+// is number of leaves in subtree `t`. The error is normalized by `ntotal`,
+// number of samples in root.
+//
+// It will return the total misclassified samples and number of leaves in
+// subtree `node`.
+//
+func findWeakestLinks(node *binary.BTNode, ntotal float64,
+	minAlpha *float64, links *[]*binary.BTNode,
+) (
+	miss, nleaf int,
+) {
+	nodev := node.Value.(NodeValue)
+
+	if nodev.IsLeaf {
+		return nodev.Miss, 1
+	}
+
+	lmiss, lleaf := findWeakestLinks(node.Left, ntotal, minAlpha, links)
+	rmiss, rleaf := findWeakestLinks(node.Right, ntotal, minAlpha, links)
+
+	miss = lmiss + rmiss
+	nleaf = lleaf + rleaf
+
+	alpha := float64(nodev.Miss-miss) / float64(nleaf-1) / ntotal
+
+	if len(*links) == 0 || alpha < *minAlpha {
+		*minAlpha = alpha
+		*links = []*binary.BTNode{node}
+	} else if alpha == *minAlpha {
+		*links = append(*links, node)
+	}
+
+	return miss, nleaf
+}
+
+//
+// pruneWeakestLinks will collapse all weakest links in tree if their
+// cost-complexity is less or equal to `maxAlpha`.
+//
+// It will return the cost-complexity of pruned nodes and true if any node has
+// been pruned.
+//
+func pruneWeakestLinks(root *binary.BTNode, maxAlpha float64) (
+	alpha float64, pruned bool,
+) {
+	ntotal := float64(root.Value.(NodeValue).Size)
+	if ntotal <= 0 {
+		return 0, false
+	}
+
+	var links []*binary.BTNode
+
+	findWeakestLinks(root, ntotal, &alpha, &links)
+
+	if len(links) == 0 || alpha > maxAlpha {
+		return alpha, false
+	}
+
+	for _, node := range links {
+		collapse(node)
+	}
+
+	return alpha, true
+}
+
+//
+// CostComplexityPath return the sequence of nested subtrees from minimal
+// cost-complexity pruning, ordered by increasing alpha.
+// The first subtree is the smallest subtree with the same resubstitution
+// error as the full tree, and the last subtree is the root only.
+//
+// The tree in runtime is not modified.
+//
+// Algorithm,
+//
+// (1) Prune all nodes which does not decrease the resubstitution error.
+// (2) Repeat until root become a leaf,
+// (2.1) collapse all weakest links and save the subtree with their
+// cost-complexity as alpha.
+//
+func (runtime *Runtime) CostComplexityPath() (steps []PruneStep) {
+	root := cloneNode(runtime.Tree.Root)
+	if root == nil {
+		return nil
+	}
+
+	// (1)
+	for {
+		_, pruned := pruneWeakestLinks(root, 0)
+		if !pruned {
+			break
+		}
+	}
+
+	steps = append(steps, PruneStep{
+		Alpha: 0,
+		NLeaf: root.LeafCount(),
+		Tree:  binary.Tree{Root: cloneNode(root)},
+	})
+
+	// (2)
+	for !root.Value.(NodeValue).IsLeaf {
+		// (2.1)
+		alpha, pruned := pruneWeakestLinks(root, math.Inf(1))
+		if !pruned {
+			break
+		}
+
+		steps = append(steps, PruneStep{
+			Alpha: alpha,
+			NLeaf: root.LeafCount(),
+			Tree:  binary.Tree{Root: cloneNode(root)},
+		})
+	}
+
+	if DEBUG >= 1 {
+		for _, step := range steps {
+			fmt.Printf("[cart] prune alpha: %f, leaves: %d\n",
+				step.Alpha, step.NLeaf)
+		}
+	}
+
+	return steps
+}
+
+//
+// PruneByAlpha will prune the tree in runtime, by collapsing all nodes which
+// cost-complexity is less or equal to `alpha`.
+//
+func (runtime *Runtime) PruneByAlpha(alpha float64) {
+	if runtime.Tree.Root == nil {
+		return
+	}
+
+	for {
+		_, pruned := pruneWeakestLinks(runtime.Tree.Root, alpha)
+		if !pruned {
+			return
+		}
+	}
+}
+
+//
+// countError return number of misclassified samples in `testset` using tree
+// `root`.
+//
+func countError(root *binary.BTNode, testset tabula.ClasetInterface) (
+	nerr int,
+) {
+	actuals := testset.GetClassAsStrings()
+	rows := testset.GetRows()
+
+	for x, row := range *rows {
+		class := findLeaf(root, row).Value.(NodeValue).Class
+		if class != actuals[x] {
+			nerr++
+		}
+	}
+	return
+}
+
+//
+// selectSubtree return the smallest subtree in pruning sequence which alpha
+// is less or equal to `alpha`.
+//
+func selectSubtree(steps []PruneStep, alpha float64) *binary.BTNode {
+	x := 0
+	for ; x < len(steps)-1; x++ {
+		if steps[x+1].Alpha > alpha {
+			break
+		}
+	}
+	return steps[x].Tree.Root
+}
+
+//
+// PruneByTestSet will prune the tree by selecting the subtree in
+// cost-complexity sequence which has the minimum error on `testset`.
+// If more than one subtree has the same error, the smallest subtree will be
+// selected.
+//
+// It will return the alpha of selected subtree.
+//
+func (runtime *Runtime) PruneByTestSet(testset tabula.ClasetInterface) (
+	alpha float64, e error,
+) {
+	if testset.GetNRow() <= 0 {
+		return 0, ErrPruneNoSample
+	}
+
+	steps := runtime.CostComplexityPath()
+	if len(steps) == 0 {
+		return 0, nil
+	}
+
+	best := 0
+	minErr := -1
+	for x, step := range steps {
+		nerr := countError(step.Tree.Root, testset)
+
+		if DEBUG >= 1 {
+			fmt.Printf("[cart] prune alpha: %f, test error: %d\n",
+				step.Alpha, nerr)
+		}
+
+		if minErr < 0 || nerr <= minErr {
+			minErr = nerr
+			best = x
+		}
+	}
+
+	runtime.Tree = steps[best].Tree
+
+	return steps[best].Alpha, nil
+}
+
+//
+// selectRows return new dataset which only contain rows in `D` where their
+// index is set in `keep`.
+//
+func selectRows(D tabula.ClasetInterface, keep []bool) (
+	sub tabula.ClasetInterface,
+) {
+	sub = D.Clone().(tabula.ClasetInterface)
+	sub.SetClassIndex(D.GetClassIndex())
+
+	for x, isKeep := range keep {
+		if isKeep {
+			sub.PushRow(D.GetRow(x))
+		}
+	}
+
+	resetColumnFlags(sub)
+
+	return sub
+}
+
+//
+// resetColumnFlags will clear flag in all columns.
+//
+func resetColumnFlags(D tabula.ClasetInterface) {
+	cols := D.GetColumns()
+	for x := range *cols {
+		(*cols)[x].Flag = 0
+	}
+}
+
+//
+// PruneByCV will prune the tree by selecting the complexity parameter using
+// k-fold cross-validation on samples `D`, which must be the same samples
+// used to build the tree.
+//
+// It will return the alpha of selected subtree.
+//
+// Algorithm,
+//
+// (1) Compute the cost-complexity sequence on the tree.
+// (2) For each alpha in sequence, use the geometric mean between alpha and
+// the next alpha as representative value.
+// (3) Split the samples into k-fold.
+// (4) For each fold,
+// (4.1) build new tree without the fold, and compute their cost-complexity
+// sequence.
+// (4.2) For each representative alpha, count the error of subtree on the
+// fold.
+// (5) Select the subtree which have the minimum total error.
+//
+func (runtime *Runtime) PruneByCV(D tabula.ClasetInterface, nfold int) (
+	alpha float64, e error,
+) {
+	nrow := D.GetNRow()
+
+	if nfold <= 1 {
+		nfold = DefPruneNFold
+	}
+	if nrow < nfold {
+		return 0, ErrPruneNoSample
+	}
+
+	// (1)
+	steps := runtime.CostComplexityPath()
+	if len(steps) <= 1 {
+		return 0, nil
+	}
+
+	// (2)
+	betas := make([]float64, len(steps))
+	for x := 0; x < len(steps)-1; x++ {
+		betas[x] = math.Sqrt(steps[x].Alpha * steps[x+1].Alpha)
+	}
+	betas[len(steps)-1] = math.Inf(1)
+
+	// (3)
+	var perm []int
+	if runtime.Rand != nil {
+		perm = runtime.Rand.Perm(nrow)
+	} else {
+		perm = rand.Perm(nrow)
+	}
+	errs := make([]int, len(steps))
+
+	for fold := 0; fold < nfold; fold++ {
+		intest := make([]bool, nrow)
+		intrain := make([]bool, nrow)
+
+		for x, idx := range perm {
+			if x%nfold == fold {
+				intest[idx] = true
+			} else {
+				intrain[idx] = true
+			}
+		}
+
+		trainset := selectRows(D, intrain)
+		testset := selectRows(D, intest)
+
+		// (4.1)
+		foldrt := Runtime{
+			SplitMethod:         runtime.SplitMethod,
+			NRandomFeature:      runtime.NRandomFeature,
+			MaxDepth:            runtime.MaxDepth,
+			MinSamplesSplit:     runtime.MinSamplesSplit,
+			MinSamplesLeaf:      runtime.MinSamplesLeaf,
+			MinImpurityDecrease: runtime.MinImpurityDecrease,
+			ReuseContinuAttr:    runtime.ReuseContinuAttr,
+			RandomSplit:         runtime.RandomSplit,
+			Rand:                runtime.Rand,
+		}
+
+		e = foldrt.Build(trainset)
+		if e != nil {
+			return 0, e
+		}
+
+		foldSteps := foldrt.CostComplexityPath()
+
+		// (4.2)
+		for x, beta := range betas {
+			root := selectSubtree(foldSteps, beta)
+			errs[x] += countError(root, testset)
+		}
+	}
+
+	// (5)
+	best := 0
+	for x := range errs {
+		if DEBUG >= 1 {
+			fmt.Printf("[cart] prune alpha: %f, cv error: %d\n",
+				steps[x].Alpha, errs[x])
+		}
+		if errs[x] <= errs[best] {
+			best = x
+		}
+	}
+
+	runtime.Tree = steps[best].Tree
+
+	return steps[best].Alpha, nil
+}
+
+//
+// buildPruneHoldout will split the samples into growing set and pruning set,
+// build the tree using growing set, and prune it using pruning set.
+//
+func (runtime *Runtime) buildPruneHoldout(D tabula.ClasetInterface) (
+	e error,
+) {
+	if runtime.PruneHoldout <= 0 || runtime.PruneHoldout >= 100 {
+		runtime.PruneHoldout = DefPruneHoldout
+	}
+
+	nrow := D.GetNRow()
+	ngrow := nrow - (nrow * runtime.PruneHoldout / 100)
+
+	if ngrow <= 0 || ngrow >= nrow {
+		return ErrPruneNoSample
+	}
+
+	grow, prune, _, _ := tabula.RandomPickRows(
+		D.(tabula.DatasetInterface), ngrow, false)
+
+	growset := grow.(tabula.ClasetInterface)
+	pruneset := prune.(tabula.ClasetInterface)
+
+	growset.SetClassIndex(D.GetClassIndex())
+	pruneset.SetClassIndex(D.GetClassIndex())
+
+	runtime.Tree.Root, e = runtime.splitTreeByGain(growset, 0)
+	if e != nil {
+		return e
+	}
+
+	_, e = runtime.PruneByTestSet(pruneset)
+
+	return e
+}
+
+//
+// buildPruneCV will build the tree using all samples and prune it using
+// cross-validation.
+//
+func (runtime *Runtime) buildPruneCV(D tabula.ClasetInterface) (e error) {
+	if runtime.PruneNFold <= 1 {
+		runtime.PruneNFold = DefPruneNFold
+	}
+
+	all := make([]bool, D.GetNRow())
+	for x := range all {
+		all[x] = true
+	}
+
+	cvset := selectRows(D, all)
+
+	runtime.Tree.Root, e = runtime.splitTreeByGain(D, 0)
+	if e != nil {
+		return e
+	}
+
+	_, e = runtime.PruneByCV(cvset, runtime.PruneNFold)
+
+	return e
+}
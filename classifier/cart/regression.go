@@ -0,0 +1,287 @@
+// Copyright 2016 Mhd Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cart
+
+import (
+	"errors"
+	"fmt"
+	"github.com/shuLhan/go-mining/gain/variance"
+	"github.com/shuLhan/go-mining/tree/binary"
+	"github.com/shuLhan/tabula"
+	"github.com/shuLhan/tekstus"
+	"sort"
+	"strconv"
+)
+
+var (
+	// ErrClassNotNumeric will be returned when building regression tree
+	// on dataset with non-numeric class attribute.
+	ErrClassNotNumeric = errors.New("cart: class attribute is not numeric")
+	// ErrPruneRegression will be returned when pruning is requested on
+	// regression tree.
+	ErrPruneRegression = errors.New("cart: pruning is not supported on" +
+		" regression tree")
+)
+
+//
+// IsRegression will return true if the tree is regression tree.
+//
+func (runtime *Runtime) IsRegression() bool {
+	return runtime.SplitMethod == SplitMethodVariance
+}
+
+//
+// buildRegression will check the input and create regression tree.
+//
+func (runtime *Runtime) buildRegression(D tabula.ClasetInterface) (e error) {
+	if D.GetClassType() == tabula.TString {
+		return ErrClassNotNumeric
+	}
+	if runtime.PruneMethod != "" {
+		return ErrPruneRegression
+	}
+
+	switch runtime.LeafPredict {
+	case LeafPredictMean, LeafPredictMedian:
+		// Do nothing.
+	default:
+		runtime.LeafPredict = LeafPredictMean
+	}
+
+	runtime.Tree.Root, e = runtime.splitTreeByVariance(D, 0)
+
+	return
+}
+
+//
+// leafValue compute the predicted value of target `T`, using mean or median.
+//
+func (runtime *Runtime) leafValue(T []float64) float64 {
+	n := len(T)
+	if n == 0 {
+		return 0
+	}
+
+	if runtime.LeafPredict == LeafPredictMedian {
+		sorted := make([]float64, n)
+		copy(sorted, T)
+		sort.Float64s(sorted)
+
+		if n%2 == 1 {
+			return sorted[n/2]
+		}
+		return (sorted[n/2-1] + sorted[n/2]) / 2
+	}
+
+	sum := 0.0
+	for _, t := range T {
+		sum += t
+	}
+	return sum / float64(n)
+}
+
+//
+// newRegressionLeaf create leaf node with predicted value from target `T`.
+//
+func (runtime *Runtime) newRegressionLeaf(T []float64) *binary.BTNode {
+	v := runtime.leafValue(T)
+
+	return &binary.BTNode{
+		Value: NodeValue{
+			IsLeaf: true,
+			Class:  strconv.FormatFloat(v, 'f', -1, 64),
+			Size:   len(T),
+			Value:  v,
+		},
+	}
+}
+
+/*
+splitTreeByVariance calculate the reduction of variance in all attributes, and
+split the dataset into two node: left and right.
+
+Return node with the split information.
+*/
+func (runtime *Runtime) splitTreeByVariance(D tabula.ClasetInterface,
+	depth int,
+) (
+	node *binary.BTNode,
+	e error,
+) {
+	nrow := D.GetNRow()
+	target := D.GetClassAsReals()
+
+	// if node reach the stopping criteria or all target has the same
+	// value, return node as leaf.
+	if runtime.isStopGrowing(nrow, depth) ||
+		variance.Compute(target) == 0 {
+		return runtime.newRegressionLeaf(target), nil
+	}
+
+	gains := runtime.computeVariance(D, target)
+
+	MaxGainIdx := variance.FindMaxGain(&gains)
+	MaxGain := gains[MaxGainIdx]
+
+	if MaxGain.GetMaxGainValue() == 0 {
+		if DEBUG >= 2 {
+			fmt.Println("[cart] max variance gain 0 with target",
+				target)
+		}
+		return runtime.newRegressionLeaf(target), nil
+	}
+
+	if runtime.isGainTooSmall(nrow, MaxGain.GetMaxGainValue()) {
+		return runtime.newRegressionLeaf(target), nil
+	}
+
+	if len(MaxGain.SortedIndex) == nrow {
+		tabula.SortColumnsByIndex(D, MaxGain.SortedIndex)
+	}
+
+	if DEBUG >= 2 {
+		fmt.Println("[cart] maxgain:", MaxGain)
+	}
+
+	var splitV interface{}
+
+	if MaxGain.IsContinu {
+		splitV = MaxGain.GetMaxPartGainValue()
+	} else {
+		attrPartV := MaxGain.GetMaxPartGainValue()
+		attrSubV := attrPartV.(tekstus.ListStrings)
+		splitV = attrSubV[0].Normalize()
+	}
+
+	v := runtime.leafValue(target)
+
+	nodev := NodeValue{
+		Class:         strconv.FormatFloat(v, 'f', -1, 64),
+		SplitAttrName: D.GetColumn(MaxGainIdx).GetName(),
+		IsLeaf:        false,
+		IsContinu:     MaxGain.IsContinu,
+		Size:          nrow,
+		SplitAttrIdx:  MaxGainIdx,
+		SplitV:        splitV,
+		Value:         v,
+		Gain:          MaxGain.GetMaxGainValue(),
+	}
+
+	splitL, splitR, e := runtime.splitNode(D, &nodev)
+	if e != nil {
+		return nil, e
+	}
+
+	// Do not create empty node, which may happen if the partition on
+	// discrete values contain value that is not exist in dataset.
+	if splitL.GetNRow() == 0 || splitR.GetNRow() == 0 ||
+		runtime.isLeafTooSmall(splitL, splitR) {
+		return runtime.newRegressionLeaf(target), nil
+	}
+
+	node = &binary.BTNode{
+		Value: nodev,
+	}
+
+	nodeLeft, e := runtime.splitTreeByVariance(splitL, depth+1)
+	if e != nil {
+		return node, e
+	}
+
+	nodeRight, e := runtime.splitTreeByVariance(splitR, depth+1)
+	if e != nil {
+		return node, e
+	}
+
+	node.SetLeft(nodeLeft)
+	node.SetRight(nodeRight)
+
+	return node, nil
+}
+
+/*
+computeVariance calculate the reduction of variance for each value in each
+attribute.
+*/
+func (runtime *Runtime) computeVariance(D tabula.ClasetInterface,
+	target []float64,
+) (
+	gains []variance.Variance,
+) {
+	gains = make([]variance.Variance, D.GetNColumn())
+	for x := range gains {
+		gains[x].MinLeaf = runtime.MinSamplesLeaf
+	}
+
+	runtime.SelectRandomFeature(D)
+
+	classIdx := D.GetClassIndex()
+
+	for x, col := range *D.GetColumns() {
+		// skip class attribute.
+		if x == classIdx {
+			gains[x].Skip = true
+			continue
+		}
+
+		// skip column flagged with parent or skip.
+		if runtime.isParentSkipped(&col) ||
+			(col.Flag&ColFlagSkip) == ColFlagSkip {
+			gains[x].Skip = true
+			continue
+		}
+
+		isContinu := col.GetType() == tabula.TReal
+
+		// the gain is computed only on rows with non-missing value.
+		known, hasMiss := knownIndex(col.Records, isContinu)
+
+		T := target
+		if hasMiss {
+			T = selectFloats(target, known)
+		}
+
+		if isContinu {
+			attr := col.ToFloatSlice()
+			if hasMiss {
+				attr = selectFloats(attr, known)
+			}
+
+			if runtime.RandomSplit {
+				part, ok := runtime.randomPartition(attr)
+				if !ok {
+					gains[x].Skip = true
+					continue
+				}
+
+				gains[x].ComputeContinuAt(&attr, &T, part)
+			} else {
+				gains[x].ComputeContinu(&attr, &T)
+			}
+		} else {
+			attr := col.ToStringSlice()
+			attrV := col.ValueSpace
+			if hasMiss {
+				attr = selectStrings(attr, known)
+				attrV = knownValues(attrV)
+			}
+			gains[x].ComputeDiscrete(&attr, &attrV, &T)
+		}
+
+		if DEBUG >= 2 {
+			fmt.Println("[cart] variance gain :", gains[x])
+		}
+	}
+	return
+}
+
+/*
+Predict return the predicted value of one sample in regression tree.
+*/
+func (runtime *Runtime) Predict(data *tabula.Row) float64 {
+	node := findLeaf(runtime.Tree.Root, data)
+
+	return node.Value.(NodeValue).Value
+}
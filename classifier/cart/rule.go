@@ -0,0 +1,292 @@
+// Copyright 2016 Mhd Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cart
+
+import (
+	"fmt"
+	"github.com/shuLhan/go-mining/tree/binary"
+	"strings"
+)
+
+const (
+	// OpLess define condition where attribute value is less than Value.
+	OpLess = "<"
+	// OpGreaterEqual define condition where attribute value is greater or
+	// equal to Value.
+	OpGreaterEqual = ">="
+	// OpIn define condition where attribute value is one of Values.
+	OpIn = "in"
+	// OpNotIn define condition where attribute value is not one of
+	// Values.
+	OpNotIn = "not in"
+)
+
+/*
+Condition define one test on attribute in rule.
+*/
+type Condition struct {
+	// AttrIdx define the index of attribute.
+	AttrIdx int `json:"AttrIdx"`
+	// AttrName define the name of attribute.
+	AttrName string `json:"AttrName"`
+	// Op define the operator, its either OpLess, OpGreaterEqual, OpIn, or
+	// OpNotIn.
+	Op string `json:"Op"`
+	// Value define the split value on continuous attribute.
+	Value float64 `json:"Value"`
+	// Values define the split values on discrete attribute.
+	Values []string `json:"Values,omitempty"`
+}
+
+/*
+String will return the condition in the form of "attr op value".
+*/
+func (cond *Condition) String() string {
+	if cond.Op == OpIn || cond.Op == OpNotIn {
+		return fmt.Sprintf("%s %s {%s}", cond.AttrName, cond.Op,
+			strings.Join(cond.Values, ", "))
+	}
+	return fmt.Sprintf("%s %s %v", cond.AttrName, cond.Op, cond.Value)
+}
+
+/*
+Rule define the path from root to one of leaf in tree, in the form of
+"IF conditions THEN class".
+*/
+type Rule struct {
+	// Conditions define list of conditions that must be satisfied by
+	// sample.
+	Conditions []Condition `json:"Conditions"`
+	// Class define the class of leaf.
+	Class string `json:"Class"`
+	// Value define the predicted value in regression tree.
+	Value float64 `json:"Value"`
+	// Coverage define number of training samples in leaf.
+	Coverage int `json:"Coverage"`
+	// Purity define the ratio of training samples in leaf which class is
+	// equal to Class. On regression tree its always zero.
+	Purity float64 `json:"Purity"`
+}
+
+/*
+String will return the rule in the form of,
+
+	IF cond AND ... THEN class = Class (coverage: N, purity: P)
+*/
+func (rule *Rule) String() string {
+	conds := make([]string, len(rule.Conditions))
+	for x := range rule.Conditions {
+		conds[x] = rule.Conditions[x].String()
+	}
+
+	s := "IF "
+	if len(conds) == 0 {
+		s += "true"
+	} else {
+		s += strings.Join(conds, " AND ")
+	}
+
+	return s + fmt.Sprintf(" THEN class = %s (coverage: %d, purity: %v)",
+		rule.Class, rule.Coverage, rule.Purity)
+}
+
+//
+// nodeCondition return the condition in node for sample that is sent to the
+// left node, if `left` is true, or to the right node.
+//
+func nodeCondition(nodev *NodeValue, left bool) (cond Condition) {
+	cond.AttrIdx = nodev.SplitAttrIdx
+	cond.AttrName = nodev.SplitAttrName
+
+	if nodev.IsContinu {
+		cond.Value, _ = nodev.SplitV.(float64)
+		if left {
+			cond.Op = OpLess
+		} else {
+			cond.Op = OpGreaterEqual
+		}
+		return cond
+	}
+
+	cond.Values, _ = nodev.SplitV.([]string)
+	if left {
+		cond.Op = OpIn
+	} else {
+		cond.Op = OpNotIn
+	}
+	return cond
+}
+
+//
+// collectRules will append the rule for each leaf in tree started from
+// `node` to `rules`, where `conds` is the conditions from root to `node`.
+//
+func (runtime *Runtime) collectRules(node *binary.BTNode, conds []Condition,
+	simplify bool, rules *[]Rule,
+) {
+	if node == nil {
+		return
+	}
+
+	nodev := node.Value.(NodeValue)
+
+	if nodev.IsLeaf {
+		rule := Rule{
+			Class:    nodev.Class,
+			Coverage: nodev.Size,
+		}
+
+		if simplify {
+			rule.Conditions = simplifyConditions(conds)
+		} else {
+			rule.Conditions = make([]Condition, len(conds))
+			copy(rule.Conditions, conds)
+		}
+
+		if runtime.IsRegression() {
+			rule.Value = nodev.Value
+		} else if nodev.Size > 0 {
+			rule.Purity = float64(nodev.Size-nodev.Miss) /
+				float64(nodev.Size)
+		}
+
+		*rules = append(*rules, rule)
+		return
+	}
+
+	n := len(conds)
+
+	conds = append(conds, nodeCondition(&nodev, true))
+	runtime.collectRules(node.Left, conds, simplify, rules)
+
+	conds = append(conds[:n], nodeCondition(&nodev, false))
+	runtime.collectRules(node.Right, conds, simplify, rules)
+}
+
+//
+// simplifyConditions will merge the conditions on the same attribute.
+// On continuous attribute, only the tightest lower and upper bound is kept.
+// On discrete attribute, all OpIn conditions is intersected, all OpNotIn
+// conditions is merged, and values in OpNotIn is removed from OpIn.
+//
+// The order of attribute is kept as the first time its appear in
+// conditions.
+//
+func simplifyConditions(conds []Condition) (simple []Condition) {
+	// index of merged condition in `simple` for each attribute and
+	// operator.
+	idx := make(map[string]int)
+
+	for _, cond := range conds {
+		key := fmt.Sprintf("%d %s", cond.AttrIdx, cond.Op)
+
+		x, ok := idx[key]
+		if !ok {
+			cond.Values = append([]string(nil), cond.Values...)
+			idx[key] = len(simple)
+			simple = append(simple, cond)
+			continue
+		}
+
+		merged := &simple[x]
+
+		switch cond.Op {
+		case OpLess:
+			if cond.Value < merged.Value {
+				merged.Value = cond.Value
+			}
+		case OpGreaterEqual:
+			if cond.Value > merged.Value {
+				merged.Value = cond.Value
+			}
+		case OpIn:
+			merged.Values = intersectStrings(merged.Values,
+				cond.Values)
+		case OpNotIn:
+			merged.Values = unionStrings(merged.Values, cond.Values)
+		}
+	}
+
+	// Remove the excluded values from included values.
+	for x := range simple {
+		if simple[x].Op != OpIn {
+			continue
+		}
+
+		key := fmt.Sprintf("%d %s", simple[x].AttrIdx, OpNotIn)
+
+		y, ok := idx[key]
+		if !ok {
+			continue
+		}
+
+		simple[x].Values = exceptStrings(simple[x].Values,
+			simple[y].Values)
+		simple[y].Op = ""
+	}
+
+	// Remove the excluded conditions that has been merged.
+	n := 0
+	for _, cond := range simple {
+		if cond.Op != "" {
+			simple[n] = cond
+			n++
+		}
+	}
+
+	return simple[:n]
+}
+
+//
+// intersectStrings return values in `a` that is also in `b`.
+//
+func intersectStrings(a, b []string) (c []string) {
+	for _, v := range a {
+		for _, w := range b {
+			if v == w {
+				c = append(c, v)
+				break
+			}
+		}
+	}
+	return
+}
+
+//
+// exceptStrings return values in `a` that is not in `b`.
+//
+func exceptStrings(a, b []string) (c []string) {
+	for _, v := range a {
+		found := false
+		for _, w := range b {
+			if v == w {
+				found = true
+				break
+			}
+		}
+		if !found {
+			c = append(c, v)
+		}
+	}
+	return
+}
+
+//
+// unionStrings return values in `a` and values in `b` that is not in `a`.
+//
+func unionStrings(a, b []string) (c []string) {
+	c = append(c, a...)
+	return append(c, exceptStrings(b, a)...)
+}
+
+/*
+Rules return one rule for each leaf in tree, ordered from the left-most leaf.
+If `simplify` is true, the conditions on the same attribute in each rule is
+merged, e.g. "a < 5 AND a < 3" become "a < 3".
+*/
+func (runtime *Runtime) Rules(simplify bool) (rules []Rule) {
+	runtime.collectRules(runtime.Tree.Root, nil, simplify, &rules)
+	return rules
+}
@@ -19,7 +19,6 @@ import (
 	"github.com/shuLhan/go-mining/classifier/rf"
 	"github.com/shuLhan/numerus"
 	"github.com/shuLhan/tabula"
-	"github.com/shuLhan/tekstus"
 	"math"
 	"os"
 	"sort"
@@ -77,6 +76,33 @@ type Runtime struct {
 	NRandomFeature int `json:"NRandomFeature"`
 	// PercentBoot percentage of bootstrap.
 	PercentBoot int `json:"PercentBoot"`
+	// MaxDepth define the maximum depth of each tree.
+	MaxDepth int `json:"MaxDepth"`
+	// MinSamplesSplit define the minimum number of samples in node to be
+	// splitted.
+	MinSamplesSplit int `json:"MinSamplesSplit"`
+	// MinSamplesLeaf define the minimum number of samples in each leaf.
+	MinSamplesLeaf int `json:"MinSamplesLeaf"`
+	// MinImpurityDecrease define the minimum weighted decrease of
+	// impurity for node to be splitted.
+	MinImpurityDecrease float64 `json:"MinImpurityDecrease"`
+	// ReuseContinuAttr if its true, continuous attribute can be used more
+	// than once in the same path of tree.
+	ReuseContinuAttr bool `json:"ReuseContinuAttr"`
+	// SoftVote if its true, the class probabilities in each stage is
+	// computed by averaging the class probabilities in each tree, instead
+	// of counting the votes.
+	SoftVote bool `json:"SoftVote"`
+	// SamplingMethod define how the samples is picked for bootstraping
+	// each tree, its either random, stratified, or balanced.
+	SamplingMethod string `json:"SamplingMethod"`
+	// SampleSizes if its not empty, define number of samples that is
+	// picked from each class for bootstraping.
+	SampleSizes map[string]int `json:"SampleSizes"`
+	// NWorkers number of trees that are grown, or samples that are
+	// predicted, concurrently. If its less or equal to zero, it will be
+	// set to number of CPU.
+	NWorkers int `json:"NWorkers"`
 
 	// forests contain forest for each stage.
 	forests []*rf.Runtime
@@ -222,8 +248,17 @@ func (crf *Runtime) createForest(samples tabula.ClasetInterface) (
 		Runtime: classifier.Runtime{
 			RunOOB: true,
 		},
-		NTree:          crf.NTree,
-		NRandomFeature: crf.NRandomFeature,
+		NTree:               crf.NTree,
+		NRandomFeature:      crf.NRandomFeature,
+		MaxDepth:            crf.MaxDepth,
+		MinSamplesSplit:     crf.MinSamplesSplit,
+		MinSamplesLeaf:      crf.MinSamplesLeaf,
+		MinImpurityDecrease: crf.MinImpurityDecrease,
+		ReuseContinuAttr:    crf.ReuseContinuAttr,
+		SoftVote:            crf.SoftVote,
+		NWorkers:            crf.NWorkers,
+		SamplingMethod:      crf.SamplingMethod,
+		SampleSizes:         crf.SampleSizes,
 	}
 
 	e = forest.Initialize(samples)
@@ -395,17 +430,84 @@ func (crf *Runtime) runTPSet(samples tabula.ClasetInterface) {
 
 //
 // ClassifySetByWeight will classify each instance in samples by weight
-// with respect to its single performance.
+// with respect to its single performance, using PredictSetByWeight, and
+// write the classifying statistic to StatFile.
+//
+// The returned `probs` contain only the probability of the first class in
+// value space; use ClassifySetByWeightProba to get the probabilities of all
+// classes.
+//
+func (crf *Runtime) ClassifySetByWeight(samples tabula.ClasetInterface,
+	sampleIds []int,
+) (
+	predicts []string, cm *classifier.CM, probs []float64,
+) {
+	predicts, cm, classProbs := crf.ClassifySetByWeightProba(samples,
+		sampleIds)
+
+	return predicts, cm, classifier.FirstProbs(classProbs)
+}
+
+//
+// ClassifySetByWeightProba is like ClassifySetByWeight, but return the
+// probabilities of all classes for each sample, ordered by class value space.
+//
+func (crf *Runtime) ClassifySetByWeightProba(samples tabula.ClasetInterface,
+	sampleIds []int,
+) (
+	predicts []string, cm *classifier.CM, probs [][]float64,
+) {
+	stat := classifier.Stat{}
+	stat.Start()
+
+	predicts, cm, probs = crf.PredictSetByWeightProba(samples, sampleIds)
+
+	crf.ComputeStatFromCM(&stat, cm)
+	stat.End()
+
+	_ = stat.Write(crf.StatFile)
+
+	return predicts, cm, probs
+}
+
+//
+// PredictSetByWeight will classify each instance in samples by weight
+// with respect to its single performance, and return their class
+// prediction, confusion matrix, and the probability of the first class in
+// value space.
+// It does not write anything, so it is safe to be called concurrently on
+// trained stages.
+//
+func (crf *Runtime) PredictSetByWeight(samples tabula.ClasetInterface,
+	sampleIds []int,
+) (
+	predicts []string, cm *classifier.CM, probs []float64,
+) {
+	predicts, cm, classProbs := crf.PredictSetByWeightProba(samples,
+		sampleIds)
+
+	return predicts, cm, classifier.FirstProbs(classProbs)
+}
+
+//
+// PredictSetByWeightProba is like PredictSetByWeight, but return the
+// probabilities of all classes for each sample, ordered by class value
+// space, which is the weighted stage probabilities that is used to select
+// the class, normalized so their sum is one.
+// The samples is divided and predicted concurrently using NWorkers
+// goroutines, but the predictions is returned in the same order as samples.
 //
 // Algorithm,
 // (1) For each instance in samples,
 // (1.1) for each stage,
-// (1.1.1) collect votes for instance in current stage.
-// (1.1.2) Compute probabilities of each classes in votes.
+// (1.1.1) Collect votes for instance in current stage, and compute
+// probabilities of each classes in votes,
 //
 //		prob_class = count_of_class / total_votes
 //
-// (1.1.3) Compute total of probabilites times of stage weight.
+// If SoftVote is true, the probabilities is the average of class
+// probabilities in each tree.
+// (1.1.2) Compute total of probabilites times of stage weight.
 //
 //		stage_prob = prob_class * stage_weight
 //
@@ -415,69 +517,63 @@ func (crf *Runtime) runTPSet(samples tabula.ClasetInterface) {
 //			(sum_of_all_weights * number_of_tree_in_forest)
 //
 // (1.3) Select class label with highest probabilites.
-// (1.4) Save stage probabilities for positive class.
+// (1.4) Save the stage probabilities, divided by their sum.
 // (2) Compute confusion matrix.
 //
-func (crf *Runtime) ClassifySetByWeight(samples tabula.ClasetInterface,
+func (crf *Runtime) PredictSetByWeightProba(samples tabula.ClasetInterface,
 	sampleIds []int,
 ) (
-	predicts []string, cm *classifier.CM, probs []float64,
+	predicts []string, cm *classifier.CM, probs [][]float64,
 ) {
-	stat := classifier.Stat{}
-	stat.Start()
-
 	vs := samples.GetClassValueSpace()
-	stageProbs := make([]float64, len(vs))
-	stageSumProbs := make([]float64, len(vs))
 	sumWeights := numerus.Floats64Sum(crf.weights)
+	rows := samples.GetDataAsRows()
+
+	predicts = make([]string, len(*rows))
+	probs = make([][]float64, len(*rows))
 
 	// (1)
-	rows := samples.GetDataAsRows()
-	for _, row := range *rows {
-		for y := range stageSumProbs {
-			stageSumProbs[y] = 0
-		}
+	classifier.ForEachRow(len(*rows), crf.NWorkers, func(x int) {
+		row := (*rows)[x]
+		stageProbs := make([]float64, len(vs))
 
 		// (1.1)
 		for y, forest := range crf.forests {
 			// (1.1.1)
-			votes := forest.Votes(row, -1)
+			forestProbs := forest.ClassProbs(row, -1, vs)
 
 			// (1.1.2)
-			probs := tekstus.WordsProbabilitiesOf(votes, vs, false)
-
-			// (1.1.3)
-			for z := range probs {
-				stageSumProbs[z] += probs[z]
-				stageProbs[z] += probs[z] * crf.weights[y]
+			for z := range forestProbs {
+				stageProbs[z] += forestProbs[z] * crf.weights[y]
 			}
 		}
 
 		// (1.2)
 		stageWeight := sumWeights * float64(crf.NTree)
 
-		for x := range stageProbs {
-			stageProbs[x] = stageProbs[x] / stageWeight
+		for z := range stageProbs {
+			stageProbs[z] = stageProbs[z] / stageWeight
 		}
 
 		// (1.3)
 		_, maxi, ok := numerus.Floats64FindMax(stageProbs)
 		if ok {
-			predicts = append(predicts, vs[maxi])
+			predicts[x] = vs[maxi]
 		}
 
-		probs = append(probs, stageSumProbs[0]/
-			float64(len(crf.forests)))
-	}
+		// (1.4)
+		sumProbs := numerus.Floats64Sum(stageProbs)
+		if sumProbs > 0 {
+			for z := range stageProbs {
+				stageProbs[z] /= sumProbs
+			}
+		}
+		probs[x] = stageProbs
+	})
 
 	// (2)
 	actuals := samples.GetClassAsStrings()
 	cm = crf.ComputeCM(sampleIds, vs, actuals, predicts)
 
-	crf.ComputeStatFromCM(&stat, cm)
-	stat.End()
-
-	_ = stat.Write(crf.StatFile)
-
 	return predicts, cm, probs
 }
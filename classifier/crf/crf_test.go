@@ -56,6 +56,29 @@ func runCRF(t *testing.T) {
 
 	fmt.Println("Confusion matrix:", cm)
 
+	// The class is selected from the returned probabilities, which sum
+	// to one.
+	vs := testset.GetClassValueSpace()
+	_, _, classProbs := crf.PredictSetByWeightProba(testset, testIds)
+
+	for x, sampleProbs := range classProbs {
+		sum := 0.0
+		maxi := 0
+		for y, p := range sampleProbs {
+			sum += p
+			if p > sampleProbs[maxi] {
+				maxi = y
+			}
+		}
+		if sum < 0.999999 || sum > 1.000001 {
+			t.Fatalf("Expecting probabilities sum to 1, got %f", sum)
+		}
+		if vs[maxi] != predicts[x] {
+			t.Fatalf("Expecting class %s from probabilities %v,"+
+				" got %s", vs[maxi], sampleProbs, predicts[x])
+		}
+	}
+
 	crf.Performance(testset, predicts, probs)
 	e = crf.WritePerformance()
 	if e != nil {
@@ -0,0 +1,99 @@
+// Copyright 2016 Mhd Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+/*
+Package et implement ensemble of classifiers using extremely randomized trees
+(Extra-Trees) algorithm by Geurts, Ernst, and Wehenkel.
+
+	Geurts, Pierre, Damien Ernst, and Louis Wehenkel. "Extremely
+	randomized trees." Machine learning 63.1 (2006): 3-42.
+
+Extra-Trees is random forest where each tree is build using all samples,
+instead of bootstrap samples, and each continuous attribute in node is
+splitted using one random value, instead of searching the best value in all
+partitions. Building Extra-Trees is faster than random forest, especially on
+dataset with many continuous attributes.
+*/
+package et
+
+import (
+	"github.com/shuLhan/go-mining/classifier/rf"
+	"github.com/shuLhan/tabula"
+)
+
+const (
+	// DefOOBStatsFile default statistic file output.
+	DefOOBStatsFile = "et.oob.stat"
+
+	// DefPerfFile default performance file output.
+	DefPerfFile = "et.perf"
+
+	// DefStatFile default statistic file.
+	DefStatFile = "et.stat"
+)
+
+/*
+Runtime contains input and output configuration when generating Extra-Trees.
+
+All trees, classification, and statistic is handled by random forest runtime.
+*/
+type Runtime struct {
+	// Runtime embed random forest, which is used to grow and run the
+	// trees.
+	rf.Runtime
+}
+
+//
+// setDefault will set the random forest to grow extremely randomized trees,
+// and set the output files to their default values if its empty.
+//
+// If SamplingMethod is empty, each tree will be build using all samples;
+// set it to other sampling method to build each tree with bootstrap samples
+// and compute the OOB statistic.
+//
+func (forest *Runtime) setDefault() {
+	forest.RandomSplit = true
+
+	if forest.SamplingMethod == "" {
+		forest.SamplingMethod = rf.SamplingNone
+	}
+	if forest.OOBStatsFile == "" {
+		forest.OOBStatsFile = DefOOBStatsFile
+	}
+	if forest.PerfFile == "" {
+		forest.PerfFile = DefPerfFile
+	}
+	if forest.StatFile == "" {
+		forest.StatFile = DefStatFile
+	}
+}
+
+//
+// Initialize will check the inputs and set it to default values if invalid,
+// before the trees is grown one by one using GrowTree.
+//
+func (forest *Runtime) Initialize(samples tabula.ClasetInterface) error {
+	forest.setDefault()
+
+	return forest.Runtime.Initialize(samples)
+}
+
+//
+// Build the Extra-Trees using samples dataset.
+//
+func (forest *Runtime) Build(samples tabula.ClasetInterface) (e error) {
+	forest.setDefault()
+
+	return forest.Runtime.Build(samples)
+}
+
+//
+// Grow will add `n` new extremely randomized trees to the forest using samples
+// dataset, without discarding the existing trees.
+//
+func (forest *Runtime) Grow(samples tabula.ClasetInterface, n int) (e error) {
+	forest.setDefault()
+
+	return forest.Runtime.Grow(samples, n)
+}
@@ -0,0 +1,65 @@
+// Copyright 2016 Mhd Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package et_test
+
+import (
+	"github.com/shuLhan/dsv"
+	"github.com/shuLhan/go-mining/classifier/et"
+	"github.com/shuLhan/go-mining/classifier/rf"
+	"github.com/shuLhan/tabula"
+	"testing"
+)
+
+func TestBuild(t *testing.T) {
+	samples := tabula.Claset{}
+	_, e := dsv.SimpleRead("../../testdata/iris/iris.dsv", &samples)
+	if nil != e {
+		t.Fatal(e)
+	}
+
+	forest := et.Runtime{
+		Runtime: rf.Runtime{
+			NTree: 20,
+			Seed:  1,
+		},
+	}
+	forest.RunOOB = true
+
+	e = forest.Build(&samples)
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	if len(forest.Trees()) != 20 {
+		t.Fatalf("Expecting 20 trees, got %d", len(forest.Trees()))
+	}
+	if forest.RunOOB {
+		t.Fatal("Expecting OOB is disabled without bootstrap")
+	}
+
+	// Each tree should be build using all samples.
+	nrow := samples.GetNRow()
+	for _, bag := range forest.Bags() {
+		if len(bag.Indices()) != nrow {
+			t.Fatalf("Expecting %d samples in bag, got %d", nrow,
+				len(bag.Indices()))
+		}
+	}
+
+	predicts, _, _ := forest.PredictSet(&samples, nil)
+	actuals := samples.GetClassAsStrings()
+
+	miss := 0
+	for x := range predicts {
+		if predicts[x] != actuals[x] {
+			miss++
+		}
+	}
+
+	if float64(miss)/float64(nrow) > 0.1 {
+		t.Fatalf("Expecting error rate less than 0.1, got %d/%d", miss,
+			nrow)
+	}
+}
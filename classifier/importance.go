@@ -0,0 +1,111 @@
+// Copyright 2016 Mhd Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package classifier
+
+import (
+	"github.com/shuLhan/dsv"
+	"github.com/shuLhan/tabula"
+	"sort"
+)
+
+//
+// Importance contain the importance value of one feature.
+//
+type Importance struct {
+	// Index of feature in dataset.
+	Index int
+	// Name of feature.
+	Name string
+	// Value of importance.
+	Value float64
+	// StdDev is the standard deviation of importance value, if the value
+	// is the mean of importance in several trees.
+	StdDev float64
+}
+
+//
+// ToRow will convert the importance to tabula.Row, with rank as the first
+// record, followed by index, name, value, and standard deviation.
+//
+func (imp *Importance) ToRow(rank int) (row *tabula.Row) {
+	row = &tabula.Row{}
+
+	row.PushBack(tabula.NewRecordInt(int64(rank)))
+	row.PushBack(tabula.NewRecordInt(int64(imp.Index)))
+	row.PushBack(tabula.NewRecordString(imp.Name))
+	row.PushBack(tabula.NewRecordReal(imp.Value))
+	row.PushBack(tabula.NewRecordReal(imp.StdDev))
+
+	return
+}
+
+//
+// Importances define list of feature importance.
+//
+type Importances []Importance
+
+//
+// Len return number of feature.
+//
+func (imps Importances) Len() int {
+	return len(imps)
+}
+
+//
+// Less will sort the importance in descending order of value, and by index
+// if the value is equal.
+//
+func (imps Importances) Less(i, j int) bool {
+	if imps[i].Value == imps[j].Value {
+		return imps[i].Index < imps[j].Index
+	}
+	return imps[i].Value > imps[j].Value
+}
+
+//
+// Swap the position of two features.
+//
+func (imps Importances) Swap(i, j int) {
+	imps[i], imps[j] = imps[j], imps[i]
+}
+
+//
+// Rank will sort the features by their importance value, from the most
+// important.
+//
+func (imps Importances) Rank() {
+	sort.Sort(imps)
+}
+
+//
+// Write will write the ranked features to `file`, one feature per line, in
+// the format of "rank,index,name,value,stddev".
+//
+func (imps Importances) Write(file string) (e error) {
+	if file == "" {
+		return
+	}
+
+	writer := &dsv.Writer{}
+	e = writer.OpenOutput(file)
+	if e != nil {
+		return e
+	}
+
+	for x := range imps {
+		e = writer.WriteRawRow(imps[x].ToRow(x+1), nil, nil)
+		if e != nil {
+			return e
+		}
+	}
+
+	return writer.Close()
+}
+
+//
+// ClassImportances contain the feature importances computed only on samples
+// of each class, where the key is the class value.
+//
+type ClassImportances map[string]Importances
@@ -0,0 +1,54 @@
+// Copyright 2016 Mhd Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package classifier
+
+import (
+	"runtime"
+	"sync"
+)
+
+//
+// ForEachRow will call `fn` for each index of row, from zero to `nrow`.
+// The rows is divided into `nworkers` contiguous shards and each shard is
+// processed by its own goroutine.
+// If `nworkers` is less or equal to zero, it will be set to number of CPU.
+//
+// Since `fn` is called concurrently, it should only write to the result at
+// index `x`.
+//
+func ForEachRow(nrow, nworkers int, fn func(x int)) {
+	if nworkers <= 0 {
+		nworkers = runtime.NumCPU()
+	}
+	if nworkers > nrow {
+		nworkers = nrow
+	}
+	if nworkers <= 1 {
+		for x := 0; x < nrow; x++ {
+			fn(x)
+		}
+		return
+	}
+
+	size := (nrow + nworkers - 1) / nworkers
+	wg := sync.WaitGroup{}
+
+	for start := 0; start < nrow; start += size {
+		end := start + size
+		if end > nrow {
+			end = nrow
+		}
+
+		wg.Add(1)
+		go func(start, end int) {
+			defer wg.Done()
+			for x := start; x < end; x++ {
+				fn(x)
+			}
+		}(start, end)
+	}
+
+	wg.Wait()
+}
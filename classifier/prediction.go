@@ -0,0 +1,76 @@
+// Copyright 2016 Mhd Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package classifier
+
+import (
+	"github.com/shuLhan/dsv"
+	"github.com/shuLhan/tabula"
+)
+
+//
+// WritePredictions will write the prediction of each sample to `file`, one
+// sample per line, in the format of
+//
+//	"index,actual,predict,prob_1,...,prob_n"
+//
+// where `prob_1` until `prob_n` is the probabilities of each class, ordered
+// by class value space.
+//
+func WritePredictions(file string, actuals, predicts []string,
+	probs [][]float64,
+) (
+	e error,
+) {
+	if file == "" {
+		return
+	}
+
+	writer := &dsv.Writer{}
+	e = writer.OpenOutput(file)
+	if e != nil {
+		return e
+	}
+
+	for x := range predicts {
+		row := &tabula.Row{}
+
+		row.PushBack(tabula.NewRecordInt(int64(x)))
+
+		if x < len(actuals) {
+			row.PushBack(tabula.NewRecordString(actuals[x]))
+		} else {
+			row.PushBack(tabula.NewRecordString(""))
+		}
+
+		row.PushBack(tabula.NewRecordString(predicts[x]))
+
+		if x < len(probs) {
+			for _, p := range probs[x] {
+				row.PushBack(tabula.NewRecordReal(p))
+			}
+		}
+
+		e = writer.WriteRawRow(row, nil, nil)
+		if e != nil {
+			return e
+		}
+	}
+
+	return writer.Close()
+}
+
+//
+// FirstProbs return the probability of the first class in value space from
+// the probabilities of all classes in each sample.
+//
+func FirstProbs(classProbs [][]float64) (probs []float64) {
+	probs = make([]float64, len(classProbs))
+	for x := range classProbs {
+		if len(classProbs[x]) > 0 {
+			probs[x] = classProbs[x][0]
+		}
+	}
+	return probs
+}
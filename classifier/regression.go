@@ -0,0 +1,200 @@
+// Copyright 2016 Mhd Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package classifier
+
+import (
+	"github.com/shuLhan/dsv"
+	"github.com/shuLhan/go-mining/gain/variance"
+	"github.com/shuLhan/tabula"
+	"math"
+	"time"
+)
+
+/*
+RegressionStat hold statistic value of regression, including mean squared
+error and explained variance.
+*/
+type RegressionStat struct {
+	// ID unique id for this statistic (e.g. number of tree).
+	ID int64
+	// StartTime contain the start time of regression in unix timestamp.
+	StartTime int64
+	// EndTime contain the end time of regression in unix timestamp.
+	EndTime int64
+	// ElapsedTime contain actual time, in seconds, between end and start
+	// time.
+	ElapsedTime int64
+	// NSample number of samples that is predicted.
+	NSample int64
+	// MSE contain the mean of squared error between actual and predicted
+	// values.
+	MSE float64
+	// RMSE contain the square root of MSE.
+	RMSE float64
+	// MAE contain the mean of absolute error between actual and
+	// predicted values.
+	MAE float64
+	// ExplainedVariance contain the proportion of variance in actual
+	// values that is explained by the predictions,
+	//
+	//	1 - (MSE / variance of actual values)
+	//
+	ExplainedVariance float64
+	// StopReason contain the reason why the regression stop training.
+	// It is only set on total statistic.
+	StopReason string
+}
+
+//
+// Compute will compute the statistic from actual and predicted values.
+// The sample where the predicted value is NaN, e.g. the sample that can not
+// be predicted, is not counted.
+//
+func (stat *RegressionStat) Compute(actuals, predicts []float64) {
+	var known []float64
+
+	stat.NSample = 0
+	stat.MSE = 0
+	stat.MAE = 0
+
+	for x, predict := range predicts {
+		if x >= len(actuals) || math.IsNaN(predict) {
+			continue
+		}
+
+		diff := actuals[x] - predict
+
+		stat.MSE += diff * diff
+		stat.MAE += math.Abs(diff)
+		stat.NSample++
+
+		known = append(known, actuals[x])
+	}
+
+	if stat.NSample == 0 {
+		stat.RMSE = 0
+		stat.ExplainedVariance = 0
+		return
+	}
+
+	stat.MSE /= float64(stat.NSample)
+	stat.MAE /= float64(stat.NSample)
+	stat.RMSE = math.Sqrt(stat.MSE)
+
+	v := variance.Compute(known)
+	if v == 0 {
+		stat.ExplainedVariance = 0
+	} else {
+		stat.ExplainedVariance = 1 - (stat.MSE / v)
+	}
+}
+
+//
+// ToRow will convert the stat to tabula.row in the order of RegressionStat
+// field. The StopReason is added only if its not empty.
+//
+func (stat *RegressionStat) ToRow() (row *tabula.Row) {
+	row = &tabula.Row{}
+
+	row.PushBack(tabula.NewRecordInt(stat.ID))
+	row.PushBack(tabula.NewRecordInt(stat.StartTime))
+	row.PushBack(tabula.NewRecordInt(stat.EndTime))
+	row.PushBack(tabula.NewRecordInt(stat.ElapsedTime))
+	row.PushBack(tabula.NewRecordInt(stat.NSample))
+	row.PushBack(tabula.NewRecordReal(stat.MSE))
+	row.PushBack(tabula.NewRecordReal(stat.RMSE))
+	row.PushBack(tabula.NewRecordReal(stat.MAE))
+	row.PushBack(tabula.NewRecordReal(stat.ExplainedVariance))
+
+	if stat.StopReason != "" {
+		row.PushBack(tabula.NewRecordString(stat.StopReason))
+	}
+
+	return
+}
+
+//
+// Start will start the timer.
+//
+func (stat *RegressionStat) Start() {
+	stat.StartTime = time.Now().Unix()
+}
+
+//
+// End will stop the timer and compute the elapsed time.
+//
+func (stat *RegressionStat) End() {
+	stat.EndTime = time.Now().Unix()
+	stat.ElapsedTime = stat.EndTime - stat.StartTime
+}
+
+//
+// Write will write the content of stat to `file`.
+//
+func (stat *RegressionStat) Write(file string) (e error) {
+	if file == "" {
+		return
+	}
+
+	writer := &dsv.Writer{}
+	e = writer.OpenOutput(file)
+	if e != nil {
+		return e
+	}
+
+	e = writer.WriteRawRow(stat.ToRow(), nil, nil)
+	if e != nil {
+		return e
+	}
+
+	return writer.Close()
+}
+
+/*
+RegressionStats define list of regression statistic values.
+*/
+type RegressionStats []*RegressionStat
+
+//
+// Add will add other stat object to the slice.
+//
+func (stats *RegressionStats) Add(stat *RegressionStat) {
+	*stats = append(*stats, stat)
+}
+
+//
+// MSEs return all mean squared error values.
+//
+func (stats *RegressionStats) MSEs() (mses []float64) {
+	mses = make([]float64, len(*stats))
+	for x, stat := range *stats {
+		mses[x] = stat.MSE
+	}
+	return
+}
+
+//
+// Write will write all statistic data to `file`.
+//
+func (stats *RegressionStats) Write(file string) (e error) {
+	if file == "" {
+		return
+	}
+
+	writer := &dsv.Writer{}
+	e = writer.OpenOutput(file)
+	if e != nil {
+		return e
+	}
+
+	for _, st := range *stats {
+		e = writer.WriteRawRow(st.ToRow(), nil, nil)
+		if e != nil {
+			return e
+		}
+	}
+
+	return writer.Close()
+}
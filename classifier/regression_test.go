@@ -0,0 +1,34 @@
+// Copyright 2016 Mhd Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package classifier_test
+
+import (
+	"github.com/shuLhan/go-mining/classifier"
+	"math"
+	"testing"
+)
+
+func TestRegressionStatCompute(t *testing.T) {
+	actuals := []float64{1, 2, 3, 4, 100}
+	predicts := []float64{1, 3, 3, 2, math.NaN()}
+
+	stat := &classifier.RegressionStat{}
+
+	stat.Compute(actuals, predicts)
+
+	// The last sample is not predicted, so it is not counted.
+	assert(t, int64(4), stat.NSample, true)
+	assert(t, 1.25, stat.MSE, true)
+	assert(t, math.Sqrt(1.25), stat.RMSE, true)
+	assert(t, 0.75, stat.MAE, true)
+
+	// Variance of actual values {1,2,3,4} is 1.25.
+	assert(t, 0.0, stat.ExplainedVariance, true)
+
+	stat.Compute(actuals[:4], actuals[:4])
+
+	assert(t, 0.0, stat.MSE, true)
+	assert(t, 1.0, stat.ExplainedVariance, true)
+}
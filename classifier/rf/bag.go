@@ -0,0 +1,102 @@
+// Copyright 2016 Mhd Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rf
+
+const (
+	bagWordSize = 64
+)
+
+//
+// Bag define the set of samples that is used to build a tree, where each
+// sample index is represented by one bit.
+// Sample with index that is greater than the size of bag is not in bag.
+//
+type Bag []uint64
+
+//
+// NewBag create new bag from list of sample index. Duplicate index, which
+// is common on bootstrap with replacement, is saved only once.
+//
+func NewBag(indices []int) (bag Bag) {
+	max := -1
+	for _, idx := range indices {
+		if idx > max {
+			max = idx
+		}
+	}
+
+	bag = make(Bag, max/bagWordSize+1)
+
+	for _, idx := range indices {
+		if idx >= 0 {
+			bag[idx/bagWordSize] |= 1 << uint(idx%bagWordSize)
+		}
+	}
+
+	return bag
+}
+
+//
+// Has will return true if sample with index `idx` is in bag.
+//
+func (bag Bag) Has(idx int) bool {
+	if idx < 0 || idx/bagWordSize >= len(bag) {
+		return false
+	}
+	return bag[idx/bagWordSize]&(1<<uint(idx%bagWordSize)) != 0
+}
+
+//
+// Indices return list of sample index in bag, in ascending order.
+//
+func (bag Bag) Indices() (indices []int) {
+	for w, word := range bag {
+		for b := 0; word != 0; b++ {
+			if word&1 == 1 {
+				indices = append(indices, w*bagWordSize+b)
+			}
+			word >>= 1
+		}
+	}
+	return indices
+}
+
+//
+// Bags return the bag of each tree in forest.
+//
+func (forest *Runtime) Bags() []Bag {
+	return forest.bags
+}
+
+//
+// isInBag will return true if sample with index `sampleIdx` is used to
+// build the tree at index `treeIdx`.
+// If the bag of tree is unknown, e.g. the forest is loaded without bag
+// indices, it will return false.
+//
+func (forest *Runtime) isInBag(treeIdx, sampleIdx int) bool {
+	if sampleIdx < 0 || treeIdx >= len(forest.bags) {
+		return false
+	}
+	return forest.bags[treeIdx].Has(sampleIdx)
+}
+
+//
+// OOBTrees return, for each sample index in training set of size
+// `nsample`, the index of trees where the sample is out-of-bag.
+//
+func (forest *Runtime) OOBTrees(nsample int) (trees [][]int) {
+	trees = make([][]int, nsample)
+
+	for x := 0; x < nsample; x++ {
+		for t := range forest.bags {
+			if !forest.bags[t].Has(x) {
+				trees[x] = append(trees[x], t)
+			}
+		}
+	}
+
+	return trees
+}
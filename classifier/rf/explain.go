@@ -0,0 +1,105 @@
+// Copyright 2016 Mhd Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rf
+
+import (
+	"fmt"
+	"github.com/shuLhan/go-mining/classifier/cart"
+	"github.com/shuLhan/numerus"
+	"github.com/shuLhan/tabula"
+	"sort"
+)
+
+/*
+Explanation contain the votes of each tree and the contribution of each
+attribute to the class predicted by forest.
+*/
+type Explanation struct {
+	// Class define the class predicted by forest.
+	Class string
+	// Probs contain the probability of each class in value space.
+	Probs []float64
+	// Votes contain the class predicted by each tree.
+	Votes []string
+	// Bias define the average probability of Class in root of each tree.
+	Bias float64
+	// Contributions contain the average contribution of each attribute
+	// to the probability of Class in all trees, ordered by their absolute
+	// value.
+	Contributions cart.Contributions
+	// Trees contain the explanation of each tree.
+	Trees []*cart.Explanation
+}
+
+/*
+String will return the votes and contributions as multiple lines text.
+*/
+func (exp *Explanation) String() (s string) {
+	s = fmt.Sprintf("class = %s, probs = %v\n", exp.Class, exp.Probs)
+	s += fmt.Sprintf("votes = %v\n", exp.Votes)
+	s += fmt.Sprintf("bias = %v\n", exp.Bias)
+	for _, contrib := range exp.Contributions {
+		s += fmt.Sprintf("%s: %v\n", contrib.AttrName, contrib.Value)
+	}
+	return s
+}
+
+/*
+Explain return the votes of each tree for `sample`, and the contribution of
+each attribute to the class predicted by forest, where `vs` is the value space
+of class.
+
+The contribution in each tree is computed using cart.ExplainClass, and then
+averaged over all trees.
+*/
+func (forest *Runtime) Explain(sample *tabula.Row, vs []string) (
+	exp *Explanation,
+) {
+	exp = &Explanation{
+		Probs: forest.ClassProbs(sample, -1, vs),
+	}
+
+	_, idx, ok := numerus.Floats64FindMax(exp.Probs)
+	if ok {
+		exp.Class = vs[idx]
+	}
+
+	ntree := len(forest.trees)
+	if ntree == 0 {
+		return exp
+	}
+
+	values := make(map[int]float64)
+
+	for x := range forest.trees {
+		treeExp := forest.trees[x].ExplainClass(sample, exp.Class)
+
+		exp.Trees = append(exp.Trees, treeExp)
+		exp.Votes = append(exp.Votes, treeExp.Class)
+		exp.Bias += treeExp.Bias
+
+		for _, contrib := range treeExp.Contributions {
+			if _, ok := values[contrib.AttrIdx]; !ok {
+				exp.Contributions = append(exp.Contributions,
+					cart.Contribution{
+						AttrIdx:  contrib.AttrIdx,
+						AttrName: contrib.AttrName,
+					})
+			}
+			values[contrib.AttrIdx] += contrib.Value
+		}
+	}
+
+	exp.Bias /= float64(ntree)
+
+	for x := range exp.Contributions {
+		exp.Contributions[x].Value = values[exp.Contributions[x].AttrIdx] /
+			float64(ntree)
+	}
+
+	sort.Stable(exp.Contributions)
+
+	return exp
+}
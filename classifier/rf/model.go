@@ -0,0 +1,215 @@
+// Copyright 2016 Mhd Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rf
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/shuLhan/go-mining/classifier"
+	"github.com/shuLhan/go-mining/classifier/cart"
+	"io"
+)
+
+const (
+	// ModelVersion define the version of forest format written by Save.
+	// Version 2 add the tree, sampling, seed, and stopping options.
+	ModelVersion = 2
+)
+
+var (
+	// ErrModelVersion will be returned by Load when the version of saved
+	// forest is unknown.
+	ErrModelVersion = errors.New("rf: unknown model version")
+	// ErrModelBagIndices will be returned by Load when the number of bag
+	// indices is not equal to number of trees.
+	ErrModelBagIndices = errors.New("rf: number of bag indices and" +
+		" trees is not equal")
+)
+
+//
+// model define the serialized form of forest.
+// Each tree is saved using the format from cart.Runtime.Save.
+// The options and the OOB statistic is saved, so new trees that is added to
+// the loaded forest is grown the same way and the statistic can be
+// continued.
+//
+type model struct {
+	Version             int                        `json:"Version"`
+	NTree               int                        `json:"NTree"`
+	NRandomFeature      int                        `json:"NRandomFeature"`
+	PercentBoot         int                        `json:"PercentBoot"`
+	MaxDepth            int                        `json:"MaxDepth,omitempty"`
+	MinSamplesSplit     int                        `json:"MinSamplesSplit,omitempty"`
+	MinSamplesLeaf      int                        `json:"MinSamplesLeaf,omitempty"`
+	MinImpurityDecrease float64                    `json:"MinImpurityDecrease,omitempty"`
+	ReuseContinuAttr    bool                       `json:"ReuseContinuAttr,omitempty"`
+	RandomSplit         bool                       `json:"RandomSplit,omitempty"`
+	SoftVote            bool                       `json:"SoftVote,omitempty"`
+	Regression          bool                       `json:"Regression,omitempty"`
+	SamplingMethod      string                     `json:"SamplingMethod,omitempty"`
+	SampleSizes         map[string]int             `json:"SampleSizes,omitempty"`
+	Seed                int64                      `json:"Seed,omitempty"`
+	StopEpsilon         float64                    `json:"StopEpsilon,omitempty"`
+	StopWindow          int                        `json:"StopWindow,omitempty"`
+	MinTree             int                        `json:"MinTree,omitempty"`
+	Trees               []json.RawMessage          `json:"Trees"`
+	BagIndices          [][]int                    `json:"BagIndices,omitempty"`
+	OOBStats            classifier.Stats           `json:"OOBStats,omitempty"`
+	OOBStatTotal        *classifier.Stat           `json:"OOBStatTotal,omitempty"`
+	OOBRegStats         classifier.RegressionStats `json:"OOBRegStats,omitempty"`
+	OOBRegTotal         *classifier.RegressionStat `json:"OOBRegTotal,omitempty"`
+}
+
+//
+// Save will write the forest configuration and all of its trees to `w` in
+// JSON format.
+// If `withBag` is true, the index of bootstrap samples for each tree is also
+// saved, so the out-of-bag samples can be computed again after loading.
+//
+func (forest *Runtime) Save(w io.Writer, withBag bool) (e error) {
+	m := model{
+		Version:             ModelVersion,
+		NTree:               forest.NTree,
+		NRandomFeature:      forest.NRandomFeature,
+		PercentBoot:         forest.PercentBoot,
+		MaxDepth:            forest.MaxDepth,
+		MinSamplesSplit:     forest.MinSamplesSplit,
+		MinSamplesLeaf:      forest.MinSamplesLeaf,
+		MinImpurityDecrease: forest.MinImpurityDecrease,
+		ReuseContinuAttr:    forest.ReuseContinuAttr,
+		RandomSplit:         forest.RandomSplit,
+		SoftVote:            forest.SoftVote,
+		Regression:          forest.Regression,
+		SamplingMethod:      forest.SamplingMethod,
+		SampleSizes:         forest.SampleSizes,
+		Seed:                forest.Seed,
+		StopEpsilon:         forest.StopEpsilon,
+		StopWindow:          forest.StopWindow,
+		MinTree:             forest.MinTree,
+		Trees:               make([]json.RawMessage, len(forest.trees)),
+	}
+
+	for x := range forest.trees {
+		buf := bytes.Buffer{}
+
+		e = forest.trees[x].Save(&buf)
+		if e != nil {
+			return e
+		}
+
+		m.Trees[x] = buf.Bytes()
+	}
+
+	if withBag {
+		m.BagIndices = make([][]int, len(forest.bags))
+		for x, bag := range forest.bags {
+			m.BagIndices[x] = bag.Indices()
+		}
+	}
+
+	m.saveStats(forest)
+
+	return json.NewEncoder(w).Encode(&m)
+}
+
+//
+// saveStats will copy the OOB statistic of forest into model, without their
+// time, so the same forest is always saved into the same model.
+//
+func (m *model) saveStats(forest *Runtime) {
+	for _, stat := range *forest.OOBStats() {
+		st := *stat
+		st.StartTime, st.EndTime, st.ElapsedTime = 0, 0, 0
+		m.OOBStats = append(m.OOBStats, &st)
+	}
+	if len(m.OOBStats) > 0 {
+		total := *forest.StatTotal()
+		total.StartTime, total.EndTime, total.ElapsedTime = 0, 0, 0
+		m.OOBStatTotal = &total
+	}
+
+	for _, stat := range *forest.OOBRegressionStats() {
+		st := *stat
+		st.StartTime, st.EndTime, st.ElapsedTime = 0, 0, 0
+		m.OOBRegStats = append(m.OOBRegStats, &st)
+	}
+	if len(m.OOBRegStats) > 0 {
+		total := *forest.RegressionStatTotal()
+		total.StartTime, total.EndTime, total.ElapsedTime = 0, 0, 0
+		m.OOBRegTotal = &total
+	}
+}
+
+//
+// Load will read the forest, that has been written by Save, from `r`.
+// All options, trees, bag indices, and OOB statistic in forest will be
+// replaced.
+// The model from version 1 does not have the tree, sampling, seed, and
+// stopping options, so they are set to their zero value.
+//
+func (forest *Runtime) Load(r io.Reader) (e error) {
+	m := model{}
+
+	e = json.NewDecoder(r).Decode(&m)
+	if e != nil {
+		return e
+	}
+
+	if m.Version <= 0 || m.Version > ModelVersion {
+		return ErrModelVersion
+	}
+
+	if len(m.BagIndices) > 0 && len(m.BagIndices) != len(m.Trees) {
+		return ErrModelBagIndices
+	}
+
+	trees := make([]cart.Runtime, len(m.Trees))
+
+	for x, raw := range m.Trees {
+		e = trees[x].Load(bytes.NewReader(raw))
+		if e != nil {
+			return e
+		}
+	}
+
+	forest.NTree = m.NTree
+	forest.NRandomFeature = m.NRandomFeature
+	forest.PercentBoot = m.PercentBoot
+	forest.MaxDepth = m.MaxDepth
+	forest.MinSamplesSplit = m.MinSamplesSplit
+	forest.MinSamplesLeaf = m.MinSamplesLeaf
+	forest.MinImpurityDecrease = m.MinImpurityDecrease
+	forest.ReuseContinuAttr = m.ReuseContinuAttr
+	forest.RandomSplit = m.RandomSplit
+	forest.SoftVote = m.SoftVote
+	forest.Regression = m.Regression
+	forest.SamplingMethod = m.SamplingMethod
+	forest.SampleSizes = m.SampleSizes
+	forest.Seed = m.Seed
+	forest.StopEpsilon = m.StopEpsilon
+	forest.StopWindow = m.StopWindow
+	forest.MinTree = m.MinTree
+	forest.trees = trees
+	forest.bags = nil
+
+	for _, bagIdx := range m.BagIndices {
+		forest.AddBagIndex(bagIdx)
+	}
+
+	forest.SetOOBStats(m.OOBStats)
+	forest.SetStatTotal(classifier.Stat{})
+	if m.OOBStatTotal != nil {
+		forest.SetStatTotal(*m.OOBStatTotal)
+	}
+
+	forest.SetOOBRegressionStats(m.OOBRegStats)
+	forest.SetRegressionStatTotal(classifier.RegressionStat{})
+	if m.OOBRegTotal != nil {
+		forest.SetRegressionStatTotal(*m.OOBRegTotal)
+	}
+
+	return nil
+}
@@ -0,0 +1,364 @@
+// Copyright 2016 Mhd Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rf
+
+import (
+	"errors"
+	"github.com/shuLhan/go-mining/classifier"
+	"github.com/shuLhan/tabula"
+	"math"
+	"math/rand"
+)
+
+var (
+	// ErrNoBag will be returned when computing out-of-bag value on forest
+	// that does not have the bag of each tree, for example forest that is
+	// loaded without bag indices.
+	ErrNoBag = errors.New("rf: forest does not have bag indices")
+	// ErrPermNoOOB will be returned when computing permutation importance
+	// on forest where RunOOB is false.
+	ErrPermNoOOB = errors.New("rf: permutation importance require RunOOB")
+	// ErrPermRegression will be returned when computing permutation
+	// importance on regression forest.
+	ErrPermRegression = errors.New("rf: permutation importance is not" +
+		" supported on regression")
+)
+
+//
+// permTree contain the increase of error in OOB samples of one tree after
+// permuting each feature.
+//
+type permTree struct {
+	// oobIdx index of OOB samples in tree.
+	oobIdx []int
+	// classNOOB number of OOB samples for each class.
+	classNOOB map[string]int
+	// miss number of misclassified OOB samples before permutation.
+	miss int
+	// classMiss number of misclassified OOB samples in each class before
+	// permutation.
+	classMiss map[string]int
+	// delta contain the increase of error rate for each feature.
+	delta []float64
+	// classDelta contain the increase of error rate for each feature in
+	// each class.
+	classDelta map[string][]float64
+}
+
+/*
+PermutationImportances compute the permutation importance of each feature,
+as defined by Breiman, using the out-of-bag samples of each tree. The
+`samples` must be the same dataset that is used to build the forest, and the
+forest must be build with RunOOB.
+
+It will return the mean and standard deviation of importance in all trees,
+for all samples and for samples in each class, ordered from the most
+important.
+It will also return the OOB statistic of forest, where the first stat is
+computed on the original samples and the next stats is computed after
+permuting each feature, with ID set to the index of the permuted feature.
+
+Algorithm,
+
+(1) For each tree in forest, concurrently, get the OOB samples of tree and
+count the misclassified OOB samples, in all and in each class.
+(2) Compute the OOB statistic of forest on original samples.
+(3) For each feature,
+(3.1) randomly permute the feature values in all samples,
+(3.2) compute the OOB statistic of forest on permuted samples,
+(3.3) for each tree, concurrently, count the misclassified OOB samples again,
+and save the increase of error rate.
+(4) Compute the mean and standard deviation of the increase of error rate
+for each feature in all trees.
+*/
+func (forest *Runtime) PermutationImportances(samples tabula.ClasetInterface) (
+	imps classifier.Importances, classImps classifier.ClassImportances,
+	stats classifier.Stats, e error,
+) {
+	if samples == nil {
+		return nil, nil, nil, ErrNoInput
+	}
+	if forest.Regression {
+		return nil, nil, nil, ErrPermRegression
+	}
+	if !forest.RunOOB {
+		return nil, nil, nil, ErrPermNoOOB
+	}
+	if len(forest.bags) < len(forest.trees) || len(forest.trees) == 0 {
+		return nil, nil, nil, ErrNoBag
+	}
+	for _, bag := range forest.bags {
+		if bag == nil {
+			return nil, nil, nil, ErrNoBag
+		}
+	}
+
+	vs := samples.GetClassValueSpace()
+	actuals := samples.GetClassAsStrings()
+	classIdx := samples.GetClassIndex()
+	ncol := samples.GetNColumn()
+	nrow := samples.GetNRow()
+	perms := make([]*permTree, len(forest.trees))
+
+	ids := make([]int, nrow)
+	for x := range ids {
+		ids[x] = x
+	}
+
+	// (1)
+	classifier.ForEachRow(len(forest.trees), forest.NWorkers, func(t int) {
+		perms[t] = forest.newPermTree(samples, actuals, ids, ncol, t)
+	})
+
+	// (2)
+	stats.Add(forest.oobStatOf(samples, ids, -1))
+
+	// (3)
+	for col := 0; col < ncol; col++ {
+		if col == classIdx {
+			continue
+		}
+
+		// (3.1)
+		rnd := rand.New(rand.NewSource(treeSeed(forest.Seed, col)))
+		permuted := permuteColumn(samples, col, rnd)
+
+		// (3.2)
+		stats.Add(forest.oobStatOf(permuted, ids, col))
+
+		// (3.3)
+		classifier.ForEachRow(len(forest.trees), forest.NWorkers,
+			func(t int) {
+				perms[t].permute(forest.treeMiss(permuted, actuals,
+					ids, t), col)
+			})
+	}
+
+	// (4)
+	classImps = make(classifier.ClassImportances, len(vs))
+
+	imps = permImportances(samples, perms, func(perm *permTree) (
+		[]float64, bool,
+	) {
+		return perm.delta, len(perm.oobIdx) > 0
+	})
+
+	for _, class := range vs {
+		class := class
+		classImps[class] = permImportances(samples, perms,
+			func(perm *permTree) ([]float64, bool) {
+				return perm.classDelta[class],
+					perm.classNOOB[class] > 0
+			})
+	}
+
+	return imps, classImps, stats, nil
+}
+
+//
+// newPermTree will get the OOB samples of tree at index `t`, using the bag
+// of tree, and count their misclassified samples before permutation.
+//
+func (forest *Runtime) newPermTree(samples tabula.ClasetInterface,
+	actuals []string, ids []int, ncol, t int,
+) (
+	perm *permTree,
+) {
+	perm = &permTree{
+		classNOOB:  make(map[string]int),
+		delta:      make([]float64, ncol),
+		classDelta: make(map[string][]float64),
+	}
+
+	for x := range actuals {
+		if !forest.isInBag(t, x) {
+			perm.oobIdx = append(perm.oobIdx, x)
+			perm.classNOOB[actuals[x]]++
+		}
+	}
+
+	for class := range perm.classNOOB {
+		perm.classDelta[class] = make([]float64, ncol)
+	}
+
+	perm.miss, perm.classMiss = perm.countMiss(forest.treeMiss(samples,
+		actuals, ids, t))
+
+	return perm
+}
+
+//
+// permute will save the increase of error rate on feature `col`, where
+// `misses` is the result of treeMiss on samples with permuted feature.
+//
+func (perm *permTree) permute(misses map[int]string, col int) {
+	if len(perm.oobIdx) == 0 {
+		return
+	}
+
+	miss, classMiss := perm.countMiss(misses)
+
+	perm.delta[col] = float64(miss-perm.miss) / float64(len(perm.oobIdx))
+
+	for class, n := range perm.classNOOB {
+		perm.classDelta[class][col] = float64(
+			classMiss[class]-perm.classMiss[class]) / float64(n)
+	}
+}
+
+//
+// countMiss return number of misclassified OOB samples of tree, in all and
+// in each class, from the result of treeMiss.
+//
+func (perm *permTree) countMiss(misses map[int]string) (
+	miss int, classMiss map[string]int,
+) {
+	classMiss = make(map[string]int)
+
+	for _, class := range misses {
+		miss++
+		classMiss[class]++
+	}
+
+	return miss, classMiss
+}
+
+//
+// treeMiss will predict the OOB samples of tree at index `t` using
+// PredictSet on forest that contain only the tree, and return the index of
+// misclassified samples with their actual class.
+//
+func (forest *Runtime) treeMiss(samples tabula.ClasetInterface,
+	actuals []string, ids []int, t int,
+) (
+	misses map[int]string,
+) {
+	one := &Runtime{
+		SoftVote: forest.SoftVote,
+		NWorkers: 1,
+		trees:    forest.trees[t : t+1],
+		bags:     forest.bags[t : t+1],
+	}
+
+	predicts, _, _ := one.PredictSet(samples, ids)
+
+	misses = make(map[int]string)
+
+	for x, predict := range predicts {
+		if one.isInBag(0, x) {
+			continue
+		}
+		if predict != actuals[x] {
+			misses[x] = actuals[x]
+		}
+	}
+
+	return misses
+}
+
+//
+// oobStatOf compute the OOB statistic of forest on `samples`, using the
+// confusion matrix from PredictSet, with ID set to `id`.
+//
+func (forest *Runtime) oobStatOf(samples tabula.ClasetInterface, ids []int,
+	id int,
+) (
+	stat *classifier.Stat,
+) {
+	stat = &classifier.Stat{}
+	stat.Start()
+
+	_, cm, _ := forest.PredictSet(samples, ids)
+
+	forest.ComputeStatFromCM(stat, cm)
+	stat.ID = int64(id)
+	stat.End()
+
+	return stat
+}
+
+//
+// permuteColumn return new dataset which contain rows in `samples` where
+// the value of feature `col` is randomly permuted between rows using random
+// source `rnd`. The original samples is not modified.
+//
+func permuteColumn(samples tabula.ClasetInterface, col int, rnd *rand.Rand) (
+	permuted tabula.ClasetInterface,
+) {
+	rows := samples.GetRows()
+	shuffled := rnd.Perm(len(*rows))
+
+	permuted = samples.Clone().(tabula.ClasetInterface)
+	permuted.SetClassIndex(samples.GetClassIndex())
+
+	for x, row := range *rows {
+		permRow := make(tabula.Row, len(*row))
+		copy(permRow, *row)
+		permRow[col] = (*(*rows)[shuffled[x]])[col]
+		permuted.PushRow(&permRow)
+	}
+
+	return permuted
+}
+
+//
+// permImportances compute the mean and standard deviation of increase of
+// error rate in each feature, using only the trees where `deltaOf` return
+// true.
+//
+func permImportances(samples tabula.ClasetInterface, perms []*permTree,
+	deltaOf func(*permTree) ([]float64, bool),
+) (
+	imps classifier.Importances,
+) {
+	classIdx := samples.GetClassIndex()
+
+	for col := 0; col < samples.GetNColumn(); col++ {
+		if col == classIdx {
+			continue
+		}
+
+		var values []float64
+		for _, perm := range perms {
+			delta, ok := deltaOf(perm)
+			if ok {
+				values = append(values, delta[col])
+			}
+		}
+
+		mean, std := meanStdDev(values)
+
+		imps = append(imps, classifier.Importance{
+			Index:  col,
+			Name:   samples.GetColumn(col).GetName(),
+			Value:  mean,
+			StdDev: std,
+		})
+	}
+
+	imps.Rank()
+
+	return imps
+}
+
+//
+// meanStdDev return the mean and population standard deviation of values.
+//
+func meanStdDev(values []float64) (mean, std float64) {
+	if len(values) == 0 {
+		return 0, 0
+	}
+
+	for _, v := range values {
+		mean += v
+	}
+	mean /= float64(len(values))
+
+	for _, v := range values {
+		std += (v - mean) * (v - mean)
+	}
+
+	return mean, math.Sqrt(std / float64(len(values)))
+}
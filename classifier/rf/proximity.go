@@ -0,0 +1,639 @@
+// Copyright 2016 Mhd Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rf
+
+import (
+	"github.com/shuLhan/dsv"
+	"github.com/shuLhan/go-mining/classifier"
+	"github.com/shuLhan/go-mining/tree/binary"
+	"github.com/shuLhan/tabula"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+const (
+	// mdsMaxIter maximum number of iteration when computing eigenvector.
+	mdsMaxIter = 1000
+	// mdsEpsilon minimum changes on eigenvector to stop the iteration.
+	mdsEpsilon = 1e-9
+)
+
+//
+// Neighbor contain the proximity of one sample to other sample.
+//
+type Neighbor struct {
+	// Index of other sample.
+	Index int
+	// Value of proximity, between zero and one.
+	Value float64
+}
+
+//
+// Proximity contain the proximity between samples in forest, which is the
+// proportion of trees where two samples fall in the same leaf.
+//
+type Proximity struct {
+	// TopK if its greater than zero, only K nearest neighbors of each
+	// sample is saved.
+	TopK int
+	// OOBOnly if its true, the proximity of two samples is computed only
+	// on trees where both samples are out-of-bag.
+	OOBOnly bool
+	// Neighbors contain the neighbors of each sample, excluding itself,
+	// that have proximity greater than zero, ordered from the nearest.
+	Neighbors [][]Neighbor
+}
+
+/*
+Proximity compute the proximity between each samples in `samples`, which is
+the number of trees where two samples fall in the same leaf divided by number
+of trees.
+
+If `topK` is greater than zero, only K nearest neighbors of each sample is
+saved, otherwise all neighbors is saved.
+If `oobOnly` is true, only trees where the samples is out-of-bag is used, and
+the proximity is divided by number of trees where both samples is out-of-bag;
+in this case the `samples` must be the same dataset that is used to build the
+forest.
+
+Algorithm,
+
+(1) Find the leaf of each sample in each tree, concurrently.
+(2) Group the samples by their leaf in each tree.
+(3) For each sample, concurrently,
+(3.1) count the samples in the same leaf in each tree,
+(3.2) divide the count with number of trees,
+(3.3) sort the neighbors and keep only K nearest.
+*/
+func (forest *Runtime) Proximity(samples tabula.ClasetInterface, topK int,
+	oobOnly bool,
+) (
+	prox *Proximity, e error,
+) {
+	if samples == nil {
+		return nil, ErrNoInput
+	}
+	if oobOnly && len(forest.bags) < len(forest.trees) {
+		return nil, ErrNoBag
+	}
+
+	rows := samples.GetRows()
+	nrow := len(*rows)
+	ntree := len(forest.trees)
+
+	isUsed := func(t, x int) bool {
+		return !oobOnly || !forest.isInBag(t, x)
+	}
+
+	// (1)
+	leaves := make([][]*binary.BTNode, nrow)
+
+	classifier.ForEachRow(nrow, forest.NWorkers, func(x int) {
+		leaves[x] = make([]*binary.BTNode, ntree)
+		for t := range forest.trees {
+			if isUsed(t, x) {
+				leaves[x][t] = forest.trees[t].Leaf((*rows)[x])
+			}
+		}
+	})
+
+	// (2)
+	members := make([]map[*binary.BTNode][]int, ntree)
+
+	for t := range forest.trees {
+		members[t] = make(map[*binary.BTNode][]int)
+		for x := range leaves {
+			if leaves[x][t] != nil {
+				members[t][leaves[x][t]] = append(
+					members[t][leaves[x][t]], x)
+			}
+		}
+	}
+
+	prox = &Proximity{
+		TopK:      topK,
+		OOBOnly:   oobOnly,
+		Neighbors: make([][]Neighbor, nrow),
+	}
+
+	// (3)
+	classifier.ForEachRow(nrow, forest.NWorkers, func(x int) {
+		// (3.1)
+		counts := make(map[int]int)
+
+		for t := range forest.trees {
+			if leaves[x][t] == nil {
+				continue
+			}
+			for _, y := range members[t][leaves[x][t]] {
+				if y != x {
+					counts[y]++
+				}
+			}
+		}
+
+		// (3.2)
+		neighbors := make([]Neighbor, 0, len(counts))
+
+		for y, count := range counts {
+			n := ntree
+			if oobOnly {
+				n = forest.countOOBTrees(x, y)
+			}
+			neighbors = append(neighbors, Neighbor{
+				Index: y,
+				Value: float64(count) / float64(n),
+			})
+		}
+
+		// (3.3)
+		sort.Sort(byProximity(neighbors))
+
+		if topK > 0 && len(neighbors) > topK {
+			neighbors = neighbors[:topK]
+		}
+
+		prox.Neighbors[x] = neighbors
+	})
+
+	return prox, nil
+}
+
+//
+// countOOBTrees return number of trees where sample `x` and `y` are both
+// out-of-bag.
+//
+func (forest *Runtime) countOOBTrees(x, y int) (n int) {
+	for t := range forest.trees {
+		if !forest.isInBag(t, x) && !forest.isInBag(t, y) {
+			n++
+		}
+	}
+	return n
+}
+
+//
+// byProximity sort the neighbors by their proximity in descending order,
+// and by their index if the proximity is equal.
+//
+type byProximity []Neighbor
+
+func (nbs byProximity) Len() int {
+	return len(nbs)
+}
+
+func (nbs byProximity) Less(i, j int) bool {
+	if nbs[i].Value == nbs[j].Value {
+		return nbs[i].Index < nbs[j].Index
+	}
+	return nbs[i].Value > nbs[j].Value
+}
+
+func (nbs byProximity) Swap(i, j int) {
+	nbs[i], nbs[j] = nbs[j], nbs[i]
+}
+
+//
+// At return the proximity between sample `x` and `y`.
+// The proximity of sample with itself is one, and the proximity with sample
+// that is not saved in neighbors is zero.
+//
+func (prox *Proximity) At(x, y int) float64 {
+	if x == y {
+		return 1
+	}
+	for _, nb := range prox.Neighbors[x] {
+		if nb.Index == y {
+			return nb.Value
+		}
+	}
+	return 0
+}
+
+/*
+Outliers return the outlier measure of each sample, where `actuals` is the
+class of each sample.
+
+The raw outlier measure of sample is the number of samples divided by sum of
+squared proximity to all samples in the same class,
+
+	raw(x) = n / sum(prox(x,y)^2), class(x) = class(y)
+
+The raw measure is then normalized in each class by subtracting their median
+and dividing it by the mean absolute deviation from median.
+Sample with value larger than ten is usually considered as outlier.
+*/
+func (prox *Proximity) Outliers(actuals []string) (scores []float64) {
+	n := float64(len(prox.Neighbors))
+	scores = make([]float64, len(prox.Neighbors))
+	classRows := make(map[string][]int)
+
+	for x, neighbors := range prox.Neighbors {
+		sum := 0.0
+		for _, nb := range neighbors {
+			if actuals[nb.Index] == actuals[x] {
+				sum += nb.Value * nb.Value
+			}
+		}
+
+		if sum == 0 {
+			// Sample without neighbor in the same class is the
+			// most outlying.
+			scores[x] = n * n
+		} else {
+			scores[x] = n / sum
+		}
+
+		classRows[actuals[x]] = append(classRows[actuals[x]], x)
+	}
+
+	for _, rows := range classRows {
+		raws := make([]float64, len(rows))
+		for x, row := range rows {
+			raws[x] = scores[row]
+		}
+
+		med := median(raws)
+
+		dev := 0.0
+		for _, raw := range raws {
+			dev += math.Abs(raw - med)
+		}
+		dev /= float64(len(raws))
+
+		for _, row := range rows {
+			scores[row] -= med
+			if dev > 0 {
+				scores[row] /= dev
+			}
+		}
+	}
+
+	return scores
+}
+
+/*
+Prototypes return one prototype sample for each class in `samples`, which
+represent how the class is related to the features.
+
+Algorithm,
+
+(1) For each class,
+(1.1) find the sample which has the largest number of the same class in
+their K nearest neighbors,
+(1.2) create the prototype using the median of continuous feature, or the
+most frequent value of discrete feature, from the sample and their K nearest
+neighbors in the same class.
+*/
+func (prox *Proximity) Prototypes(samples tabula.ClasetInterface, k int) (
+	protos tabula.Rows,
+) {
+	vs := samples.GetClassValueSpace()
+	actuals := samples.GetClassAsStrings()
+
+	// (1)
+	for _, class := range vs {
+		// (1.1)
+		var members []int
+		best := -1
+
+		for x, neighbors := range prox.Neighbors {
+			if actuals[x] != class {
+				continue
+			}
+
+			var same []int
+			for y, nb := range neighbors {
+				if y >= k {
+					break
+				}
+				if actuals[nb.Index] == class {
+					same = append(same, nb.Index)
+				}
+			}
+
+			if len(same) > best {
+				best = len(same)
+				members = append([]int{x}, same...)
+			}
+		}
+
+		if members == nil {
+			continue
+		}
+
+		// (1.2)
+		protos = append(protos, newPrototype(samples, members, class))
+	}
+
+	return protos
+}
+
+//
+// newPrototype create new sample with class `class` using the median or the
+// most frequent value of each feature in samples at index `members`.
+//
+func newPrototype(samples tabula.ClasetInterface, members []int,
+	class string,
+) (
+	proto *tabula.Row,
+) {
+	proto = &tabula.Row{}
+	classIdx := samples.GetClassIndex()
+
+	for col := 0; col < samples.GetNColumn(); col++ {
+		if col == classIdx {
+			proto.PushBack(tabula.NewRecordString(class))
+			continue
+		}
+
+		if samples.GetColumn(col).GetType() == tabula.TReal {
+			values := make([]float64, len(members))
+			for x, idx := range members {
+				values[x] = (*samples.GetRow(idx))[col].Float()
+			}
+			proto.PushBack(tabula.NewRecordReal(median(values)))
+			continue
+		}
+
+		counts := make(map[string]int)
+		mode := ""
+		for _, idx := range members {
+			v := (*samples.GetRow(idx))[col].String()
+			counts[v]++
+			if counts[v] > counts[mode] ||
+				(counts[v] == counts[mode] && v < mode) {
+				mode = v
+			}
+		}
+		proto.PushBack(tabula.NewRecordString(mode))
+	}
+
+	return proto
+}
+
+/*
+MDS compute the coordinates of each sample in `dim` dimensions using
+classical multidimensional scaling, where the distance between two samples is
+one minus their proximity.
+
+This will create a dense matrix of all samples, so it should only be used on
+small dataset.
+
+Algorithm,
+
+(1) Create matrix of squared distance between samples, from the symmetric
+proximity matrix.
+(2) Double center the matrix.
+(3) For each dimension,
+(3.1) find the largest eigenvalue and their eigenvector using power
+iteration,
+(3.2) set the coordinate to eigenvector multiplied by the square-root of
+eigenvalue,
+(3.3) remove the eigenvector from matrix.
+*/
+func (prox *Proximity) MDS(dim int) (coords [][]float64) {
+	n := len(prox.Neighbors)
+
+	// (1)
+	B := prox.symmetric()
+	for x := range B {
+		for y := range B[x] {
+			d := 1 - B[x][y]
+			B[x][y] = d * d
+		}
+	}
+
+	// (2)
+	rowMeans := make([]float64, n)
+	mean := 0.0
+	for x := range B {
+		for y := range B[x] {
+			rowMeans[x] += B[x][y]
+		}
+		mean += rowMeans[x]
+		rowMeans[x] /= float64(n)
+	}
+	mean /= float64(n * n)
+
+	for x := range B {
+		for y := range B[x] {
+			B[x][y] = -0.5 * (B[x][y] - rowMeans[x] - rowMeans[y] +
+				mean)
+		}
+	}
+
+	coords = make([][]float64, n)
+	for x := range coords {
+		coords[x] = make([]float64, dim)
+	}
+
+	rnd := rand.New(rand.NewSource(1))
+
+	// (3)
+	for d := 0; d < dim; d++ {
+		// (3.1)
+		lambda, v := powerIteration(B, rnd)
+		if lambda <= 0 {
+			break
+		}
+
+		// (3.2)
+		for x := range v {
+			coords[x][d] = v[x] * math.Sqrt(lambda)
+		}
+
+		// (3.3)
+		for x := range B {
+			for y := range B[x] {
+				B[x][y] -= lambda * v[x] * v[y]
+			}
+		}
+	}
+
+	return coords
+}
+
+//
+// symmetric return the dense proximity matrix of all samples, where the
+// proximity of sample `x` and `y` is the mean of their proximity in both
+// direction,
+//
+//	(At(x,y) + At(y,x)) / 2
+//
+// The proximity is not symmetric when only K nearest neighbors is saved.
+//
+func (prox *Proximity) symmetric() (P [][]float64) {
+	n := len(prox.Neighbors)
+
+	P = make([][]float64, n)
+	for x := range P {
+		P[x] = make([]float64, n)
+		P[x][x] = 1
+	}
+
+	for x, neighbors := range prox.Neighbors {
+		for _, nb := range neighbors {
+			P[x][nb.Index] += nb.Value / 2
+			P[nb.Index][x] += nb.Value / 2
+		}
+	}
+
+	return P
+}
+
+//
+// powerIteration return the largest eigenvalue of symmetric matrix `A` and
+// their unit eigenvector.
+//
+func powerIteration(A [][]float64, rnd *rand.Rand) (
+	lambda float64, v []float64,
+) {
+	n := len(A)
+	v = make([]float64, n)
+	for x := range v {
+		v[x] = rnd.Float64() - 0.5
+	}
+	normalize(v)
+
+	next := make([]float64, n)
+
+	for iter := 0; iter < mdsMaxIter; iter++ {
+		for x := range A {
+			next[x] = 0
+			for y := range A[x] {
+				next[x] += A[x][y] * v[y]
+			}
+		}
+
+		lambda = 0
+		for x := range v {
+			lambda += v[x] * next[x]
+		}
+
+		if normalize(next) == 0 {
+			return 0, v
+		}
+
+		diff := 0.0
+		for x := range v {
+			diff += math.Abs(next[x] - v[x])
+		}
+
+		copy(v, next)
+
+		if diff < mdsEpsilon {
+			break
+		}
+	}
+
+	return lambda, v
+}
+
+//
+// normalize will divide each value in `v` with their euclidean norm, and
+// return the norm.
+//
+func normalize(v []float64) (norm float64) {
+	for _, x := range v {
+		norm += x * x
+	}
+	norm = math.Sqrt(norm)
+
+	if norm == 0 {
+		return 0
+	}
+	for x := range v {
+		v[x] /= norm
+	}
+	return norm
+}
+
+//
+// median return the median of values, without modifying it.
+//
+func median(values []float64) float64 {
+	n := len(values)
+	if n == 0 {
+		return 0
+	}
+
+	sorted := make([]float64, n)
+	copy(sorted, values)
+	sort.Float64s(sorted)
+
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
+
+//
+// Write will write the proximity to `file`, one neighbor per line, in the
+// format of "index,neighbor-index,proximity".
+//
+func (prox *Proximity) Write(file string) (e error) {
+	if file == "" {
+		return
+	}
+
+	writer := &dsv.Writer{}
+	e = writer.OpenOutput(file)
+	if e != nil {
+		return e
+	}
+
+	for x, neighbors := range prox.Neighbors {
+		for _, nb := range neighbors {
+			row := &tabula.Row{}
+			row.PushBack(tabula.NewRecordInt(int64(x)))
+			row.PushBack(tabula.NewRecordInt(int64(nb.Index)))
+			row.PushBack(tabula.NewRecordReal(nb.Value))
+
+			e = writer.WriteRawRow(row, nil, nil)
+			if e != nil {
+				return e
+			}
+		}
+	}
+
+	return writer.Close()
+}
+
+//
+// WriteMDS will write the coordinates from MDS to `file`, one sample per
+// line, in the format of "index,coordinate-1,...,coordinate-n,class", where
+// `actuals` is the class of each sample.
+//
+func WriteMDS(file string, coords [][]float64, actuals []string) (e error) {
+	if file == "" {
+		return
+	}
+
+	writer := &dsv.Writer{}
+	e = writer.OpenOutput(file)
+	if e != nil {
+		return e
+	}
+
+	for x, coord := range coords {
+		row := &tabula.Row{}
+		row.PushBack(tabula.NewRecordInt(int64(x)))
+		for _, c := range coord {
+			row.PushBack(tabula.NewRecordReal(c))
+		}
+		if x < len(actuals) {
+			row.PushBack(tabula.NewRecordString(actuals[x]))
+		}
+
+		e = writer.WriteRawRow(row, nil, nil)
+		if e != nil {
+			return e
+		}
+	}
+
+	return writer.Close()
+}
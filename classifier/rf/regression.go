@@ -0,0 +1,159 @@
+// Copyright 2016 Mhd Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rf
+
+import (
+	"errors"
+	"fmt"
+	"github.com/shuLhan/go-mining/classifier"
+	"github.com/shuLhan/tabula"
+	"math"
+)
+
+var (
+	// ErrSamplingRegression will be returned when sampling by class, or
+	// sample sizes of each class, is used on regression forest.
+	ErrSamplingRegression = errors.New("rf: sampling by class is not" +
+		" supported on regression")
+)
+
+//
+// addRegressionTree will compute the OOB statistic of the grown tree on
+// regression forest, using the mean squared error of OOB samples, and write
+// it to the OOB statistic file.
+//
+func (forest *Runtime) addRegressionTree(grown *grownTree) (e error) {
+	stat := &classifier.RegressionStat{
+		ID:        int64(grown.id),
+		StartTime: grown.stat.StartTime,
+	}
+
+	if forest.RunOOB {
+		predicts := forest.PredictValues(grown.oob, grown.oobIdx)
+		stat.Compute(grown.oob.GetClassAsReals(), predicts)
+	}
+
+	stat.End()
+
+	if DEBUG >= 2 && forest.RunOOB {
+		fmt.Println(tag, "OOB regression stat:", stat)
+	}
+
+	forest.AddRegressionStat(stat)
+
+	return forest.WriteOOBRegressionStat(stat)
+}
+
+//
+// finalizeRegression will compute the total OOB statistic of regression
+// forest, where each sample in `samples` is predicted only by the trees
+// where the sample is out-of-bag, and write it to the OOB statistic file.
+//
+func (forest *Runtime) finalizeRegression(samples tabula.ClasetInterface,
+	stopReason string,
+) error {
+	total := forest.RegressionStatTotal()
+
+	if forest.RunOOB {
+		ids := make([]int, samples.GetNRow())
+		for x := range ids {
+			ids[x] = x
+		}
+
+		predicts := forest.PredictValues(samples, ids)
+		total.Compute(samples.GetClassAsReals(), predicts)
+	}
+
+	total.StopReason = stopReason
+
+	if DEBUG >= 1 {
+		fmt.Println(tag, "OOB regression stat total:", total)
+	}
+
+	return forest.FinalizeRegression()
+}
+
+//
+// Predict return the predicted value of one sample in regression forest,
+// which is the mean of predicted values in all trees.
+// If `sampleIdx` is not negative, the tree where the sample is used for
+// training will not be counted.
+// If no tree can predict the sample, it will return NaN.
+//
+func (forest *Runtime) Predict(sample *tabula.Row, sampleIdx int) float64 {
+	sum := 0.0
+	n := 0
+
+	for x := range forest.trees {
+		if forest.isInBag(x, sampleIdx) {
+			continue
+		}
+
+		sum += forest.trees[x].Predict(sample)
+		n++
+	}
+
+	if n == 0 {
+		return math.NaN()
+	}
+
+	return sum / float64(n)
+}
+
+//
+// PredictValues will predict the value of each sample in `samples` using
+// regression forest.
+// If `sampleIds` is not nil, then sample index will be checked in each tree,
+// if the sample is used for training, their prediction is not counted.
+//
+// The samples is divided and predicted concurrently using NWorkers
+// goroutines, but the predictions is returned in the same order as samples.
+//
+func (forest *Runtime) PredictValues(samples tabula.ClasetInterface,
+	sampleIds []int,
+) (
+	predicts []float64,
+) {
+	rows := samples.GetRows()
+	predicts = make([]float64, len(*rows))
+
+	classifier.ForEachRow(len(*rows), forest.NWorkers, func(x int) {
+		sampleIdx := -1
+		if len(sampleIds) > 0 {
+			sampleIdx = sampleIds[x]
+		}
+		predicts[x] = forest.Predict((*rows)[x], sampleIdx)
+	})
+
+	return predicts
+}
+
+//
+// RegressSet will predict the value of each sample in `samples`, compute
+// the regression statistic, and write it to StatFile.
+//
+func (forest *Runtime) RegressSet(samples tabula.ClasetInterface) (
+	predicts []float64, stat *classifier.RegressionStat,
+) {
+	stat = &classifier.RegressionStat{}
+	stat.Start()
+
+	if DEBUG >= 1 {
+		fmt.Println(tag, "Regress set:", samples)
+	}
+
+	predicts = forest.PredictValues(samples, nil)
+
+	stat.Compute(samples.GetClassAsReals(), predicts)
+	stat.End()
+
+	if DEBUG >= 1 {
+		fmt.Println(tag, "Regression stat:", stat)
+	}
+
+	_ = stat.Write(forest.StatFile)
+
+	return predicts, stat
+}
@@ -21,8 +21,12 @@ import (
 	"github.com/shuLhan/tabula"
 	"github.com/shuLhan/tekstus"
 	"math"
+	"math/rand"
 	"os"
+	"runtime"
 	"strconv"
+	"sync"
+	"time"
 )
 
 const (
@@ -43,6 +47,10 @@ const (
 
 	// DefStatFile default statistic file.
 	DefStatFile = "rf.stat"
+
+	// maxTreeRetry maximum number of bootstraping a tree, when the tree
+	// can not be build from the bootstrap samples.
+	maxTreeRetry = 10
 )
 
 var (
@@ -68,14 +76,72 @@ type Runtime struct {
 	NRandomFeature int `json:"NRandomFeature"`
 	// PercentBoot percentage of sample for bootstraping.
 	PercentBoot int `json:"PercentBoot"`
+	// MaxDepth define the maximum depth of each tree.
+	MaxDepth int `json:"MaxDepth"`
+	// MinSamplesSplit define the minimum number of samples in node to be
+	// splitted.
+	MinSamplesSplit int `json:"MinSamplesSplit"`
+	// MinSamplesLeaf define the minimum number of samples in each leaf.
+	MinSamplesLeaf int `json:"MinSamplesLeaf"`
+	// MinImpurityDecrease define the minimum weighted decrease of
+	// impurity for node to be splitted.
+	MinImpurityDecrease float64 `json:"MinImpurityDecrease"`
+	// ReuseContinuAttr if its true, continuous attribute can be used more
+	// than once in the same path of tree.
+	ReuseContinuAttr bool `json:"ReuseContinuAttr"`
+	// RandomSplit if its true, the continuous attribute in each tree is
+	// splitted using random value, as in extremely randomized trees.
+	RandomSplit bool `json:"RandomSplit"`
+	// Regression if its true, the forest is build as regression forest,
+	// where the class attribute must be numeric, each tree is splitted by
+	// reduction of variance, and the predicted value is the mean of
+	// predicted values in all trees. The OOB statistic is computed using
+	// mean squared error and explained variance, instead of confusion
+	// matrix.
+	Regression bool `json:"Regression"`
+	// SoftVote if its true, the class probabilities in forest is computed
+	// by averaging the class probabilities in each tree, instead of
+	// counting the votes.
+	SoftVote bool `json:"SoftVote"`
+	// NWorkers number of trees that are grown, or samples that are
+	// predicted, concurrently. If its less or equal to zero, it will be set
+	// to number of CPU.
+	NWorkers int `json:"NWorkers"`
+	// SamplingMethod define how the samples is picked for bootstraping
+	// each tree, its either random, stratified, balanced, or none.
+	// Default is random.
+	SamplingMethod string `json:"SamplingMethod"`
+	// SampleSizes if its not empty, define number of samples that is
+	// picked from each class for bootstraping, where the key is the class
+	// value. Class that is not defined use the size from SamplingMethod.
+	SampleSizes map[string]int `json:"SampleSizes"`
+	// StopEpsilon if its greater than zero, enable early stopping, where
+	// the forest will stop growing the trees when the mean of OOB error in
+	// the last StopWindow trees changes less than StopEpsilon.
+	StopEpsilon float64 `json:"StopEpsilon"`
+	// StopWindow number of last trees where the mean of OOB error is
+	// checked for convergence. Default is 10.
+	StopWindow int `json:"StopWindow"`
+	// MinTree minimum number of trees in forest before early stopping is
+	// checked. Default is StopWindow. The maximum number of trees is NTree.
+	MinTree int `json:"MinTree"`
+	// Seed for random number generator. Each tree has their own random
+	// source, seeded with the hash of Seed and tree ID, so the same seed
+	// will produce the same forest regardless of number of workers.
+	// If its zero, it will be set to current time.
+	Seed int64 `json:"Seed"`
 
 	// nSubsample number of samples used for bootstraping.
 	nSubsample int
+	// strata contain index of samples in each stratum for bootstraping.
+	strata [][]int
+	// strataSizes contain number of samples picked in each stratum.
+	strataSizes []int
 	// trees contain all tree in the forest.
 	trees []cart.Runtime
-	// bagIndices contain list of index of selected samples at bootstraping
-	// for book-keeping.
-	bagIndices [][]int
+	// bags contain the samples that are selected at bootstraping for
+	// each tree, for book-keeping.
+	bags []Bag
 }
 
 func init() {
@@ -104,7 +170,7 @@ func (forest *Runtime) AddCartTree(tree cart.Runtime) {
 AddBagIndex add bagging index for book keeping.
 */
 func (forest *Runtime) AddBagIndex(bagIndex []int) {
-	forest.bagIndices = append(forest.bagIndices, bagIndex)
+	forest.bags = append(forest.bags, NewBag(bagIndex))
 }
 
 //
@@ -114,6 +180,8 @@ func (forest *Runtime) AddBagIndex(bagIndex []int) {
 //
 //	number-of-sample * percentage-of-bootstrap
 //
+// If forest already has trees, the statistic file will be opened for
+// appending, so the new trees can be added to the forest.
 //
 func (forest *Runtime) Initialize(samples tabula.ClasetInterface) error {
 	if forest.NTree <= 0 {
@@ -122,10 +190,21 @@ func (forest *Runtime) Initialize(samples tabula.ClasetInterface) error {
 	if forest.PercentBoot <= 0 {
 		forest.PercentBoot = DefPercentBoot
 	}
+	if forest.Regression && samples.GetClassType() == tabula.TString {
+		return cart.ErrClassNotNumeric
+	}
 	if forest.NRandomFeature <= 0 {
-		// Set default value to square-root of features.
 		ncol := samples.GetNColumn() - 1
-		forest.NRandomFeature = int(math.Sqrt(float64(ncol)))
+		if forest.Regression {
+			// Set default value to one-third of features.
+			forest.NRandomFeature = ncol / 3
+			if forest.NRandomFeature <= 0 {
+				forest.NRandomFeature = 1
+			}
+		} else {
+			// Set default value to square-root of features.
+			forest.NRandomFeature = int(math.Sqrt(float64(ncol)))
+		}
 	}
 	if forest.OOBStatsFile == "" {
 		forest.OOBStatsFile = DefOOBStatsFile
@@ -136,10 +215,33 @@ func (forest *Runtime) Initialize(samples tabula.ClasetInterface) error {
 	if forest.StatFile == "" {
 		forest.StatFile = DefStatFile
 	}
+	if forest.NWorkers <= 0 {
+		forest.NWorkers = runtime.NumCPU()
+	}
+	if forest.Seed == 0 {
+		forest.Seed = time.Now().UnixNano()
+	}
 
 	forest.nSubsample = int(float32(samples.GetNRow()) *
 		(float32(forest.PercentBoot) / 100.0))
 
+	forest.initStopping()
+
+	e := forest.initSampling(samples)
+	if e != nil {
+		return e
+	}
+
+	if len(forest.trees) > 0 {
+		// Keep the bag of each tree at the same index as their tree,
+		// when the previous trees does not have bag.
+		for len(forest.bags) < len(forest.trees) {
+			forest.bags = append(forest.bags, nil)
+		}
+
+		return forest.Runtime.Resume()
+	}
+
 	return forest.Runtime.Initialize()
 }
 
@@ -148,11 +250,20 @@ Build the forest using samples dataset.
 
 Algorithm,
 
-(0) Recheck input value: number of tree, percentage bootstrap, etc; and
+If forest already has trees, for example the forest has been build before or
+loaded from model, the existing trees is kept and only the remaining trees
+will be grown, until the forest has NTree trees. The `samples` must be the
+same dataset that is used to grow the existing trees.
+
+Algorithm,
+
+(0) If forest already has NTree trees, return immediately.
+(0.1) Recheck input value: number of tree, percentage bootstrap, etc; and
     Open statistic file output.
-(1) For 0 to NTree,
-(1.1) Create new tree, repeat until all trees has been build.
-(2) Compute and write total statistic.
+(1) Grow the remaining trees, NTree minus number of existing trees, using
+NWorkers concurrently, or until the mean of OOB error has converged if
+StopEpsilon is set.
+(2) Compute and write total statistic, including the reason of stopping.
 */
 func (forest *Runtime) Build(samples tabula.ClasetInterface) (e error) {
 	// check input samples
@@ -161,6 +272,14 @@ func (forest *Runtime) Build(samples tabula.ClasetInterface) (e error) {
 	}
 
 	// (0)
+	if forest.NTree <= 0 {
+		forest.NTree = DefNumTree
+	}
+	if len(forest.trees) >= forest.NTree {
+		return nil
+	}
+
+	// (0.1)
 	e = forest.Initialize(samples)
 	if e != nil {
 		return
@@ -171,24 +290,166 @@ func (forest *Runtime) Build(samples tabula.ClasetInterface) (e error) {
 	fmt.Println(tag, "Forest config   :", forest)
 
 	// (1)
-	for t := 0; t < forest.NTree; t++ {
-		if DEBUG >= 1 {
-			fmt.Println(tag, "tree #", t)
+	converged, e := forest.growTrees(samples,
+		forest.NTree-len(forest.trees))
+	if e != nil {
+		return e
+	}
+
+	// (2)
+	stopReason := StopReasonNTree
+	if converged {
+		stopReason = StopReasonConverged
+	}
+
+	if DEBUG >= 1 {
+		fmt.Println(tag, "Stop after", len(forest.trees), "trees:",
+			stopReason)
+	}
+
+	if forest.Regression {
+		return forest.finalizeRegression(samples, stopReason)
+	}
+
+	forest.StatTotal().StopReason = stopReason
+
+	return forest.Finalize()
+}
+
+/*
+Grow will add `n` new trees to the forest using samples dataset, without
+discarding the existing trees, and set NTree to the new number of trees.
+The OOB statistic is continued from the last tree, and written by appending
+it to the OOB statistic file.
+
+The forest that is loaded from model continue the OOB statistic that is
+saved in the model. If the forest is loaded without bag indices, the existing
+trees will vote on all OOB samples.
+*/
+func (forest *Runtime) Grow(samples tabula.ClasetInterface, n int) (e error) {
+	if n <= 0 {
+		return nil
+	}
+
+	forest.NTree = len(forest.trees) + n
+
+	return forest.Build(samples)
+}
+
+//
+// grownTree contain the tree with their bootstrap samples, as the result of
+// newTree.
+//
+type grownTree struct {
+	id     int
+	tree   *cart.Runtime
+	oob    tabula.ClasetInterface
+	bagIdx []int
+	oobIdx []int
+	stat   *classifier.Stat
+	e      error
+}
+
+/*
+growTrees will grow `ntree` trees concurrently and add them to forest.
+It will return true if the growing is stopped early because the OOB error
+has converged.
+If one of the tree can not be build, it will stop growing and return the
+error.
+
+Algorithm,
+
+(1) Run NWorkers workers, each of them build a tree with ID received from
+channel, and send back the result.
+(2) Send the ID of each new tree to workers, until all trees has been sent or
+the OOB error has converged.
+(3) Add each tree to forest ordered by their ID, which mean the tree that is
+finished early must wait until all trees with lower ID has been added.
+(3.1) If the tree can not be build or the OOB error has converged, stop
+sending new tree and discard the trees that has been grown after it.
+*/
+func (forest *Runtime) growTrees(samples tabula.ClasetInterface, ntree int) (
+	converged bool, e error,
+) {
+	start := len(forest.trees)
+	ids := make(chan int)
+	results := make(chan *grownTree, forest.NWorkers)
+	done := make(chan struct{})
+	wg := sync.WaitGroup{}
+
+	// (1)
+	for w := 0; w < forest.NWorkers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for id := range ids {
+				results <- forest.newTree(samples, id)
+			}
+		}()
+	}
+
+	// (2)
+	go func() {
+	send:
+		for id := start; id < start+ntree; id++ {
+			select {
+			case ids <- id:
+			case <-done:
+				break send
+			}
 		}
+		close(ids)
+		wg.Wait()
+		close(results)
+	}()
 
-		// (1.1)
-		for {
-			_, _, e = forest.GrowTree(samples)
-			if e == nil {
+	// (3)
+	pending := make(map[int]*grownTree)
+	next := start
+	stop := false
+
+	for grown := range results {
+		if stop {
+			continue
+		}
+
+		pending[grown.id] = grown
+
+		for !stop {
+			grown, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			next++
+
+			// (3.1)
+			if grown.e != nil {
+				e = grown.e
+				stop = true
+				close(done)
 				break
 			}
 
-			fmt.Println(tag, "error:", e)
+			if DEBUG >= 1 {
+				fmt.Println(tag, "tree #", grown.id)
+			}
+
+			_, _, err := forest.addTree(grown)
+			if err != nil && e == nil {
+				e = err
+			}
+
+			// (3.1)
+			if forest.isConverged() {
+				converged = true
+				stop = true
+				close(done)
+			}
 		}
 	}
 
-	// (2)
-	return forest.Finalize()
+	return converged, e
 }
 
 /*
@@ -203,43 +464,124 @@ Algorithm,
 (4) Save index of random samples for calculating error rate later.
 (5) Run OOB on forest.
 (6) Calculate OOB error rate and statistic values.
+
+On regression forest, the returned `cm` is nil and the OOB statistic of tree
+is saved in OOBRegressionStats.
 */
 func (forest *Runtime) GrowTree(samples tabula.ClasetInterface) (
 	cm *classifier.CM, stat *classifier.Stat, e error,
 ) {
-	stat = &classifier.Stat{}
-	stat.ID = int64(len(forest.trees))
-	stat.Start()
-
-	// (1)
-	bag, oob, bagIdx, oobIdx := tabula.RandomPickRows(
-		samples.(tabula.DatasetInterface),
-		forest.nSubsample, true)
+	grown := forest.newTree(samples, len(forest.trees))
+	if grown.e != nil {
+		return nil, nil, grown.e
+	}
 
-	bagset := bag.(tabula.ClasetInterface)
+	return forest.addTree(grown)
+}
 
-	if DEBUG >= 2 {
-		bagset.RecountMajorMinor()
-		fmt.Println(tag, "Bagging:", bagset)
+//
+// newTree will build tree with ID `id` using bootstrap samples from
+// `samples`.
+// The random source for bootstraping and selecting features in tree is
+// seeded with treeSeed of Seed and `id`.
+// If tree can not be build, it will repeat with new bootstrap samples, up to
+// maxTreeRetry times, before returning the last error in `grown.e`.
+//
+func (forest *Runtime) newTree(samples tabula.ClasetInterface, id int) (
+	grown *grownTree,
+) {
+	grown = &grownTree{
+		id:   id,
+		stat: &classifier.Stat{},
 	}
+	grown.stat.ID = int64(id)
+	grown.stat.Start()
 
-	// (2)
-	cart, e := cart.New(bagset, cart.SplitMethodGini,
-		forest.NRandomFeature)
-	if e != nil {
-		return nil, nil, e
+	rnd := rand.New(rand.NewSource(treeSeed(forest.Seed, id)))
+
+	for retry := 0; retry < maxTreeRetry; retry++ {
+		// (1)
+		bag, oob, bagIdx, oobIdx := forest.bootstrap(samples, rnd)
+
+		if DEBUG >= 2 {
+			bag.RecountMajorMinor()
+			fmt.Println(tag, "Bagging:", bag)
+		}
+
+		// (2)
+		splitMethod := cart.SplitMethodGini
+		if forest.Regression {
+			splitMethod = cart.SplitMethodVariance
+		}
+
+		tree := &cart.Runtime{
+			SplitMethod:         splitMethod,
+			NRandomFeature:      forest.NRandomFeature,
+			MaxDepth:            forest.MaxDepth,
+			MinSamplesSplit:     forest.MinSamplesSplit,
+			MinSamplesLeaf:      forest.MinSamplesLeaf,
+			MinImpurityDecrease: forest.MinImpurityDecrease,
+			ReuseContinuAttr:    forest.ReuseContinuAttr,
+			RandomSplit:         forest.RandomSplit,
+			Rand:                rnd,
+		}
+
+		grown.e = tree.Build(bag)
+		if grown.e != nil {
+			fmt.Println(tag, "error:", grown.e)
+			continue
+		}
+
+		tree.Rand = nil
+
+		grown.tree = tree
+		grown.oob = oob
+		grown.bagIdx = bagIdx
+		grown.oobIdx = oobIdx
+
+		return grown
 	}
 
+	return grown
+}
+
+//
+// treeSeed return the seed of random source for tree with ID `id`, by mixing
+// the forest `seed` and `id` using SplitMix64, so the forests with
+// consecutive seeds does not share the random source of their trees.
+//
+func treeSeed(seed int64, id int) int64 {
+	z := uint64(seed) + (uint64(id)+1)*0x9e3779b97f4a7c15
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	z ^= z >> 31
+
+	return int64(z)
+}
+
+//
+// addTree will add the grown tree to forest, and compute their OOB
+// statistic using all trees in forest.
+//
+func (forest *Runtime) addTree(grown *grownTree) (
+	cm *classifier.CM, stat *classifier.Stat, e error,
+) {
+	stat = grown.stat
+
 	// (3)
-	forest.AddCartTree(*cart)
+	forest.AddCartTree(*grown.tree)
 
 	// (4)
-	forest.AddBagIndex(bagIdx)
+	forest.AddBagIndex(grown.bagIdx)
+
+	if forest.Regression {
+		e = forest.addRegressionTree(grown)
+		return nil, stat, e
+	}
 
 	// (5)
 	if forest.RunOOB {
-		oobset := oob.(tabula.ClasetInterface)
-		_, cm, _ = forest.ClassifySet(oobset, oobIdx)
+		_, cm, _ = forest.PredictSet(grown.oob, grown.oobIdx)
 
 		forest.AddOOBCM(cm)
 	}
@@ -275,22 +617,34 @@ func (forest *Runtime) GrowTree(samples tabula.ClasetInterface) (
 // If `sampleIds` is not nil, then sample index will be checked in each tree,
 // if the sample is used for training, their vote is not counted.
 //
+// The returned `probs` contain only the probability of the first class in
+// value space; use ClassifySetProba to get the probabilities of all classes.
+//
+func (forest *Runtime) ClassifySet(samples tabula.ClasetInterface,
+	sampleIds []int,
+) (
+	predicts []string, cm *classifier.CM, probs []float64,
+) {
+	predicts, cm, classProbs := forest.ClassifySetProba(samples, sampleIds)
+
+	return predicts, cm, classifier.FirstProbs(classProbs)
+}
+
+//
+// ClassifySetProba is like ClassifySet, but return the probabilities of all
+// classes for each sample, ordered by class value space.
+//
 // Algorithm,
 //
-// (0) Get value space (possible class values in dataset)
-// (1) For each row in test-set,
-// (1.1) collect votes in all trees,
-// (1.2) select majority class vote, and
-// (1.3) compute and save the actual class probabilities.
-// (2) Compute confusion matrix from predictions.
-// (3) Compute stat from confusion matrix.
-// (4) Write the stat to file only if sampleIds is empty, which mean its run
+// (1) Predict all samples using PredictSetProba.
+// (2) Compute stat from confusion matrix.
+// (3) Write the stat to file only if sampleIds is empty, which mean its run
 // not from OOB set.
 //
-func (forest *Runtime) ClassifySet(samples tabula.ClasetInterface,
+func (forest *Runtime) ClassifySetProba(samples tabula.ClasetInterface,
 	sampleIds []int,
 ) (
-	predicts []string, cm *classifier.CM, probs []float64,
+	predicts []string, cm *classifier.CM, probs [][]float64,
 ) {
 	stat := classifier.Stat{}
 	stat.Start()
@@ -301,46 +655,90 @@ func (forest *Runtime) ClassifySet(samples tabula.ClasetInterface,
 			samples.GetRow(0))
 	}
 
+	// (1)
+	predicts, cm, probs = forest.PredictSetProba(samples, sampleIds)
+
+	// (2)
+	forest.ComputeStatFromCM(&stat, cm)
+	stat.End()
+
+	// (3)
+	if len(sampleIds) <= 0 {
+		fmt.Println(tag, "CM:", cm)
+		fmt.Println(tag, "Classifying stat:", stat)
+		_ = stat.Write(forest.StatFile)
+	}
+
+	return predicts, cm, probs
+}
+
+//
+// PredictSet will predict the class of each sample in `samples`, and return
+// their class prediction, confusion matrix, and the probability of the
+// first class in value space.
+// The `sampleIds` is used in the same way as in ClassifySet.
+//
+// Unlike ClassifySet, it does not print or write anything, so it is safe to
+// be called concurrently on trained forest.
+//
+func (forest *Runtime) PredictSet(samples tabula.ClasetInterface,
+	sampleIds []int,
+) (
+	predicts []string, cm *classifier.CM, probs []float64,
+) {
+	predicts, cm, classProbs := forest.PredictSetProba(samples, sampleIds)
+
+	return predicts, cm, classifier.FirstProbs(classProbs)
+}
+
+//
+// PredictSetProba is like PredictSet, but return the probabilities of all
+// classes for each sample, ordered by class value space.
+//
+// The samples is divided and predicted concurrently using NWorkers
+// goroutines, but the predictions is returned in the same order as samples.
+//
+// Algorithm,
+//
+// (0) Get value space (possible class values in dataset)
+// (1) For each row in test-set,
+// (1.1) collect votes in all trees, or the average of class probabilities
+// in all trees if SoftVote is true,
+// (1.2) select majority class vote.
+// (2) Compute confusion matrix from predictions.
+//
+func (forest *Runtime) PredictSetProba(samples tabula.ClasetInterface,
+	sampleIds []int,
+) (
+	predicts []string, cm *classifier.CM, probs [][]float64,
+) {
 	// (0)
 	vs := samples.GetClassValueSpace()
 	actuals := samples.GetClassAsStrings()
-	sampleIdx := -1
+	rows := samples.GetRows()
+
+	predicts = make([]string, len(*rows))
+	probs = make([][]float64, len(*rows))
 
 	// (1)
-	rows := samples.GetRows()
-	for x, row := range *rows {
+	classifier.ForEachRow(len(*rows), forest.NWorkers, func(x int) {
 		// (1.1)
+		sampleIdx := -1
 		if len(sampleIds) > 0 {
 			sampleIdx = sampleIds[x]
 		}
-		votes := forest.Votes(row, sampleIdx)
+		probs[x] = forest.ClassProbs((*rows)[x], sampleIdx, vs)
 
 		// (1.2)
-		classProbs := tekstus.WordsProbabilitiesOf(votes, vs, false)
-
-		_, idx, ok := numerus.Floats64FindMax(classProbs)
-
+		_, idx, ok := numerus.Floats64FindMax(probs[x])
 		if ok {
-			predicts = append(predicts, vs[idx])
+			predicts[x] = vs[idx]
 		}
-
-		// (1.3)
-		probs = append(probs, classProbs[0])
-	}
+	})
 
 	// (2)
 	cm = forest.ComputeCM(sampleIds, vs, actuals, predicts)
 
-	// (3)
-	forest.ComputeStatFromCM(&stat, cm)
-	stat.End()
-
-	if len(sampleIds) <= 0 {
-		fmt.Println(tag, "CM:", cm)
-		fmt.Println(tag, "Classifying stat:", stat)
-		_ = stat.Write(forest.StatFile)
-	}
-
 	return predicts, cm, probs
 }
 
@@ -358,12 +756,8 @@ func (forest *Runtime) Votes(sample *tabula.Row, sampleIdx int) (
 ) {
 	for x, tree := range forest.trees {
 		// (1)
-		if sampleIdx >= 0 {
-			exist := numerus.IntsIsExist(forest.bagIndices[x],
-				sampleIdx)
-			if exist {
-				continue
-			}
+		if forest.isInBag(x, sampleIdx) {
+			continue
 		}
 
 		// (2)
@@ -374,3 +768,97 @@ func (forest *Runtime) Votes(sample *tabula.Row, sampleIdx int) (
 	}
 	return votes
 }
+
+//
+// VotesProba will return the average of class probabilities, ordered by
+// value space `vs`, from each tree based on sample.
+// The `sampleIdx` is used in the same way as in Votes.
+//
+func (forest *Runtime) VotesProba(sample *tabula.Row, sampleIdx int,
+	vs []string,
+) (
+	probs []float64,
+) {
+	probs = make([]float64, len(vs))
+	ntree := 0
+
+	for x, tree := range forest.trees {
+		if forest.isInBag(x, sampleIdx) {
+			continue
+		}
+
+		treeProbs := tree.ClassifyProba(sample)
+
+		for y, class := range vs {
+			probs[y] += treeProbs[class]
+		}
+		ntree++
+	}
+
+	if ntree > 0 {
+		for y := range probs {
+			probs[y] /= float64(ntree)
+		}
+	}
+
+	return probs
+}
+
+//
+// ClassProbs will return the probability of each class in value space `vs`
+// for sample.
+// If SoftVote is true, it will return the average probabilities from
+// VotesProba, otherwise it will return the proportion of votes from Votes.
+//
+func (forest *Runtime) ClassProbs(sample *tabula.Row, sampleIdx int,
+	vs []string,
+) []float64 {
+	if forest.SoftVote {
+		return forest.VotesProba(sample, sampleIdx, vs)
+	}
+
+	votes := forest.Votes(sample, sampleIdx)
+
+	return tekstus.WordsProbabilitiesOf(votes, vs, false)
+}
+
+//
+// FeatureImportances return the mean decrease of impurity for each feature,
+// averaged over all trees in forest, ordered from the most important.
+// The importance in each tree is normalized before averaging, so the total
+// importance is one.
+//
+func (forest *Runtime) FeatureImportances() (imps classifier.Importances) {
+	ntree := len(forest.trees)
+	if ntree == 0 {
+		return nil
+	}
+
+	values := make(map[int][]float64)
+	names := make(map[int]string)
+
+	for x := range forest.trees {
+		for _, imp := range forest.trees[x].FeatureImportances() {
+			if values[imp.Index] == nil {
+				values[imp.Index] = make([]float64, ntree)
+			}
+			values[imp.Index][x] = imp.Value
+			names[imp.Index] = imp.Name
+		}
+	}
+
+	for idx, v := range values {
+		mean, std := meanStdDev(v)
+
+		imps = append(imps, classifier.Importance{
+			Index:  idx,
+			Name:   names[idx],
+			Value:  mean,
+			StdDev: std,
+		})
+	}
+
+	imps.Rank()
+
+	return imps
+}
@@ -5,12 +5,16 @@
 package rf_test
 
 import (
+	"bytes"
 	"fmt"
 	"github.com/shuLhan/dsv"
 	"github.com/shuLhan/go-mining/classifier"
 	"github.com/shuLhan/go-mining/classifier/rf"
 	"github.com/shuLhan/tabula"
 	"log"
+	"math"
+	"reflect"
+	"sync"
 	"testing"
 )
 
@@ -178,3 +182,922 @@ func TestWvc2010Lnsmote(t *testing.T) {
 
 	runRandomForest()
 }
+
+func TestSaveLoad(t *testing.T) {
+	samples := tabula.Claset{}
+	_, e := dsv.SimpleRead("../../testdata/iris/iris.dsv", &samples)
+	if nil != e {
+		t.Fatal(e)
+	}
+
+	forest := rf.Runtime{
+		Runtime: classifier.Runtime{
+			OOBStatsFile: "iris.save.oob",
+		},
+		NTree:    10,
+		SoftVote: true,
+	}
+
+	e = forest.Build(&samples)
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	buf := bytes.Buffer{}
+
+	e = forest.Save(&buf, true)
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	loaded := rf.Runtime{}
+
+	e = loaded.Load(&buf)
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	if len(loaded.Trees()) != len(forest.Trees()) {
+		t.Fatalf("Expecting %d trees, got %d", len(forest.Trees()),
+			len(loaded.Trees()))
+	}
+	if !loaded.SoftVote {
+		t.Fatal("Expecting loaded forest with soft vote")
+	}
+
+	exp, _, expProbs := forest.ClassifySetProba(&samples, nil)
+	got, _, gotProbs := loaded.ClassifySetProba(&samples, nil)
+
+	if !reflect.DeepEqual(exp, got) {
+		t.Fatalf("Expecting predictions %v, got %v", exp, got)
+	}
+	if !reflect.DeepEqual(expProbs, gotProbs) {
+		t.Fatalf("Expecting probabilities %v, got %v", expProbs,
+			gotProbs)
+	}
+}
+
+func TestNWorkers(t *testing.T) {
+	samples := tabula.Claset{}
+	_, e := dsv.SimpleRead("../../testdata/iris/iris.dsv", &samples)
+	if nil != e {
+		t.Fatal(e)
+	}
+
+	var models [][]byte
+	var oobErrors [][]float64
+
+	for _, nworkers := range []int{1, 4} {
+		forest := rf.Runtime{
+			Runtime: classifier.Runtime{
+				RunOOB:       true,
+				OOBStatsFile: "iris.workers.oob",
+			},
+			NTree:    20,
+			NWorkers: nworkers,
+			Seed:     7,
+		}
+
+		e = forest.Build(&samples)
+		if e != nil {
+			t.Fatal(e)
+		}
+
+		buf := bytes.Buffer{}
+
+		e = forest.Save(&buf, true)
+		if e != nil {
+			t.Fatal(e)
+		}
+
+		var errs []float64
+		for x, stat := range *forest.OOBStats() {
+			if stat.ID != int64(x) {
+				t.Fatalf("Expecting stat ID %d, got %d", x, stat.ID)
+			}
+			errs = append(errs, stat.OobError)
+		}
+
+		models = append(models, buf.Bytes())
+		oobErrors = append(oobErrors, errs)
+	}
+
+	if !bytes.Equal(models[0], models[1]) {
+		t.Fatal("Expecting the same forest with different workers")
+	}
+	if !reflect.DeepEqual(oobErrors[0], oobErrors[1]) {
+		t.Fatalf("Expecting OOB errors %v, got %v", oobErrors[0],
+			oobErrors[1])
+	}
+}
+
+func TestGrow(t *testing.T) {
+	samples := tabula.Claset{}
+	_, e := dsv.SimpleRead("../../testdata/iris/iris.dsv", &samples)
+	if nil != e {
+		t.Fatal(e)
+	}
+
+	newForest := func(ntree int) *rf.Runtime {
+		return &rf.Runtime{
+			Runtime: classifier.Runtime{
+				RunOOB:       true,
+				OOBStatsFile: "iris.grow.oob",
+			},
+			NTree: ntree,
+			Seed:  3,
+		}
+	}
+
+	full := newForest(15)
+	e = full.Build(&samples)
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	warm := newForest(10)
+	e = warm.Build(&samples)
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	e = warm.Grow(&samples, 5)
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	exp := bytes.Buffer{}
+	got := bytes.Buffer{}
+
+	e = full.Save(&exp, true)
+	if e != nil {
+		t.Fatal(e)
+	}
+	e = warm.Save(&got, true)
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	if !bytes.Equal(exp.Bytes(), got.Bytes()) {
+		t.Fatal("Expecting the same forest after growing the trees")
+	}
+
+	stats := *warm.OOBStats()
+	if len(stats) != 15 {
+		t.Fatalf("Expecting 15 OOB stats, got %d", len(stats))
+	}
+	for x, stat := range stats {
+		if stat.ID != int64(x) {
+			t.Fatalf("Expecting stat ID %d, got %d", x, stat.ID)
+		}
+		if stat.OobError != (*full.OOBStats())[x].OobError {
+			t.Fatalf("Expecting OOB error %v, got %v",
+				(*full.OOBStats())[x].OobError, stat.OobError)
+		}
+	}
+
+	// Continue growing the loaded forest.
+	loaded := newForest(0)
+	e = loaded.Load(&got)
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	loaded.NTree = 20
+	e = loaded.Build(&samples)
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	if len(loaded.Trees()) != 20 || len(loaded.Bags()) != 20 {
+		t.Fatalf("Expecting 20 trees and bags, got %d and %d",
+			len(loaded.Trees()), len(loaded.Bags()))
+	}
+
+	// The OOB statistic is continued from the saved model.
+	if len(*loaded.OOBStats()) != 20 {
+		t.Fatalf("Expecting 20 OOB stats, got %d",
+			len(*loaded.OOBStats()))
+	}
+	if loaded.StatTotal().ID != 20 {
+		t.Fatalf("Expecting total stat ID 20, got %d",
+			loaded.StatTotal().ID)
+	}
+
+	// Build the forest that already has NTree trees should not add new
+	// tree nor statistic.
+	e = loaded.Build(&samples)
+	if e != nil {
+		t.Fatal(e)
+	}
+	if len(loaded.Trees()) != 20 || len(*loaded.OOBStats()) != 20 {
+		t.Fatalf("Expecting 20 trees and stats, got %d and %d",
+			len(loaded.Trees()), len(*loaded.OOBStats()))
+	}
+
+	// The options in saved model is used to grow the loaded forest.
+	shallow := newForest(15)
+	shallow.MaxDepth = 2
+	shallow.SamplingMethod = rf.SamplingStratified
+
+	e = shallow.Build(&samples)
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	shallowWarm := newForest(10)
+	shallowWarm.MaxDepth = 2
+	shallowWarm.SamplingMethod = rf.SamplingStratified
+
+	e = shallowWarm.Build(&samples)
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	got.Reset()
+	e = shallowWarm.Save(&got, true)
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	loaded = newForest(0)
+	e = loaded.Load(&got)
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	if loaded.MaxDepth != 2 {
+		t.Fatalf("Expecting max depth 2, got %d", loaded.MaxDepth)
+	}
+	if loaded.SamplingMethod != rf.SamplingStratified {
+		t.Fatalf("Expecting sampling method %s, got %s",
+			rf.SamplingStratified, loaded.SamplingMethod)
+	}
+
+	e = loaded.Grow(&samples, 5)
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	exp.Reset()
+	got.Reset()
+
+	e = shallow.Save(&exp, true)
+	if e != nil {
+		t.Fatal(e)
+	}
+	e = loaded.Save(&got, true)
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	if !bytes.Equal(exp.Bytes(), got.Bytes()) {
+		t.Fatal("Expecting the same forest after growing the loaded" +
+			" forest")
+	}
+}
+
+func TestEarlyStopping(t *testing.T) {
+	samples := tabula.Claset{}
+	_, e := dsv.SimpleRead("../../testdata/iris/iris.dsv", &samples)
+	if nil != e {
+		t.Fatal(e)
+	}
+
+	const (
+		ntree   = 100
+		window  = 5
+		epsilon = 0.01
+	)
+
+	forest := rf.Runtime{
+		Runtime: classifier.Runtime{
+			RunOOB:       true,
+			OOBStatsFile: "iris.stop.oob",
+		},
+		NTree:       ntree,
+		NWorkers:    4,
+		Seed:        1,
+		StopEpsilon: epsilon,
+		StopWindow:  window,
+		MinTree:     10,
+	}
+
+	e = forest.Build(&samples)
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	n := len(forest.Trees())
+	if n < 10 || n >= ntree {
+		t.Fatalf("Expecting stop between 10 and %d trees, got %d",
+			ntree, n)
+	}
+
+	means := forest.OOBStats().OobErrorMeans()
+	if len(means) != n {
+		t.Fatalf("Expecting %d OOB stats, got %d", n, len(means))
+	}
+
+	// The forest should stop at the first tree where the mean of OOB
+	// error in the last window has converged.
+	isConverged := func(means []float64) bool {
+		min, max := means[0], means[0]
+		for _, v := range means[1:] {
+			min = math.Min(min, v)
+			max = math.Max(max, v)
+		}
+		return max-min < epsilon
+	}
+
+	for x := 10; x <= n; x++ {
+		got := isConverged(means[x-window : x])
+		if got != (x == n) {
+			t.Fatalf("Expecting converged %v at %d trees, got %v",
+				x == n, x, got)
+		}
+	}
+
+	reason := forest.StatTotal().StopReason
+	if reason != rf.StopReasonConverged {
+		t.Fatalf("Expecting stop reason %s, got %s",
+			rf.StopReasonConverged, reason)
+	}
+
+	// Without early stopping, all trees should be grown.
+	forest.StopEpsilon = 0
+	e = forest.Grow(&samples, 5)
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	if len(forest.Trees()) != n+5 {
+		t.Fatalf("Expecting %d trees, got %d", n+5,
+			len(forest.Trees()))
+	}
+
+	reason = forest.StatTotal().StopReason
+	if reason != rf.StopReasonNTree {
+		t.Fatalf("Expecting stop reason %s, got %s",
+			rf.StopReasonNTree, reason)
+	}
+}
+
+func TestRegression(t *testing.T) {
+	samples := tabula.Claset{}
+	_, e := dsv.SimpleRead("../../testdata/forensic_glass/glass_ri.dsv",
+		&samples)
+	if nil != e {
+		t.Fatal(e)
+	}
+
+	forest := rf.Runtime{
+		Runtime: classifier.Runtime{
+			RunOOB:       true,
+			OOBStatsFile: "glass_ri.oob",
+			StatFile:     "glass_ri.stat",
+		},
+		NTree:      20,
+		Seed:       1,
+		Regression: true,
+	}
+
+	e = forest.Build(&samples)
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	if len(*forest.OOBRegressionStats()) != 20 {
+		t.Fatalf("Expecting 20 OOB regression stats, got %d",
+			len(*forest.OOBRegressionStats()))
+	}
+
+	total := forest.RegressionStatTotal()
+	if total.ExplainedVariance <= 0 {
+		t.Fatalf("Expecting positive OOB explained variance, got %v",
+			total.ExplainedVariance)
+	}
+	if total.StopReason != rf.StopReasonNTree {
+		t.Fatalf("Expecting stop reason %s, got %s",
+			rf.StopReasonNTree, total.StopReason)
+	}
+
+	// The model should keep the regression mode.
+	buf := bytes.Buffer{}
+
+	e = forest.Save(&buf, false)
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	loaded := rf.Runtime{}
+	e = loaded.Load(&buf)
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	if !loaded.Regression {
+		t.Fatal("Expecting loaded forest as regression")
+	}
+
+	exp := forest.PredictValues(&samples, nil)
+	got := loaded.PredictValues(&samples, nil)
+	if !reflect.DeepEqual(exp, got) {
+		t.Fatalf("Expecting predictions %v, got %v", exp, got)
+	}
+
+	_, stat := forest.RegressSet(&samples)
+	if stat.MSE >= total.MSE {
+		t.Fatalf("Expecting training MSE less than OOB MSE %v, got %v",
+			total.MSE, stat.MSE)
+	}
+}
+
+func TestPredictSet(t *testing.T) {
+	samples := tabula.Claset{}
+	_, e := dsv.SimpleRead("../../testdata/iris/iris.dsv", &samples)
+	if nil != e {
+		t.Fatal(e)
+	}
+
+	forest := rf.Runtime{
+		Runtime: classifier.Runtime{
+			OOBStatsFile: "iris.predict.oob",
+		},
+		NTree:    10,
+		NWorkers: 1,
+		Seed:     1,
+	}
+
+	e = forest.Build(&samples)
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	exp, expCM, expProbs := forest.PredictSet(&samples, nil)
+
+	forest.NWorkers = 4
+
+	// Predict the samples from several goroutines at once.
+	var wg sync.WaitGroup
+	errs := make(chan string, 4)
+
+	for x := 0; x < 4; x++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			got, cm, probs := forest.PredictSet(&samples, nil)
+
+			if !reflect.DeepEqual(exp, got) ||
+				!reflect.DeepEqual(expProbs, probs) ||
+				cm.String() != expCM.String() {
+				errs <- fmt.Sprintf("expecting %v, got %v", exp, got)
+			}
+		}()
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Fatal(err)
+	}
+}
+
+func TestPredictSetProba(t *testing.T) {
+	samples := tabula.Claset{}
+	_, e := dsv.SimpleRead("../../testdata/iris/iris.dsv", &samples)
+	if nil != e {
+		t.Fatal(e)
+	}
+
+	forest := rf.Runtime{
+		Runtime: classifier.Runtime{
+			OOBStatsFile: "iris.proba.oob",
+		},
+		NTree: 10,
+		Seed:  1,
+	}
+
+	e = forest.Build(&samples)
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	vs := samples.GetClassValueSpace()
+
+	predicts, _, classProbs := forest.PredictSetProba(&samples, nil)
+	_, _, probs := forest.PredictSet(&samples, nil)
+
+	for x, rowProbs := range classProbs {
+		if len(rowProbs) != len(vs) {
+			t.Fatalf("Expecting %d probabilities, got %d", len(vs),
+				len(rowProbs))
+		}
+
+		sum := 0.0
+		for _, p := range rowProbs {
+			sum += p
+		}
+		if math.Abs(sum-1) > 1e-9 {
+			t.Fatalf("Expecting sum of probabilities 1, got %v", sum)
+		}
+
+		if rowProbs[0] != probs[x] {
+			t.Fatalf("Expecting first probability %v, got %v",
+				probs[x], rowProbs[0])
+		}
+
+		if predicts[x] == "" {
+			t.Fatalf("Expecting prediction on sample %d", x)
+		}
+	}
+}
+
+func TestBag(t *testing.T) {
+	bag := rf.NewBag([]int{3, 0, 70, 3, 64})
+
+	exp := []int{0, 3, 64, 70}
+	if !reflect.DeepEqual(exp, bag.Indices()) {
+		t.Fatalf("Expecting indices %v, got %v", exp, bag.Indices())
+	}
+
+	for _, idx := range []int{-1, 1, 63, 65, 128} {
+		if bag.Has(idx) {
+			t.Fatalf("Expecting %d is not in bag", idx)
+		}
+	}
+	for _, idx := range exp {
+		if !bag.Has(idx) {
+			t.Fatalf("Expecting %d is in bag", idx)
+		}
+	}
+}
+
+func TestOOBTrees(t *testing.T) {
+	forest := rf.Runtime{}
+
+	forest.AddBagIndex([]int{0, 1, 1})
+	forest.AddBagIndex([]int{1, 2})
+	forest.AddBagIndex([]int{0, 2, 0})
+
+	exp := [][]int{{1}, {2}, {0}, {0, 1, 2}}
+	got := forest.OOBTrees(4)
+
+	if !reflect.DeepEqual(exp, got) {
+		t.Fatalf("Expecting OOB trees %v, got %v", exp, got)
+	}
+}
+
+func TestPermutationImportances(t *testing.T) {
+	samples := tabula.Claset{}
+	_, e := dsv.SimpleRead("../../testdata/iris/iris.dsv", &samples)
+	if nil != e {
+		t.Fatal(e)
+	}
+
+	forest := rf.Runtime{
+		Runtime: classifier.Runtime{
+			OOBStatsFile: "iris.perm.oob",
+		},
+		NTree: 20,
+		Seed:  3,
+	}
+
+	e = forest.Build(&samples)
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	_, _, _, e = forest.PermutationImportances(&samples)
+	if e != rf.ErrPermNoOOB {
+		t.Fatalf("Expecting error %v, got %v", rf.ErrPermNoOOB, e)
+	}
+
+	forest = rf.Runtime{
+		Runtime: classifier.Runtime{
+			RunOOB:       true,
+			OOBStatsFile: "iris.perm.oob",
+		},
+		NTree: 20,
+		Seed:  3,
+	}
+
+	e = forest.Build(&samples)
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	imps, classImps, stats, e := forest.PermutationImportances(&samples)
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	if len(imps) != 4 {
+		t.Fatalf("Expecting 4 features, got %d", len(imps))
+	}
+
+	// One of petal attributes should be the most important feature.
+	if imps[0].Name != "petal-length" && imps[0].Name != "petal-width" {
+		t.Fatalf("Expecting petal as the most important, got %v", imps)
+	}
+
+	for _, class := range samples.GetClassValueSpace() {
+		if len(classImps[class]) != 4 {
+			t.Fatalf("Expecting 4 features in class %s, got %v",
+				class, classImps[class])
+		}
+	}
+
+	// One stat on original samples, and one stat for each feature.
+	if len(stats) != 5 {
+		t.Fatalf("Expecting 5 stats, got %d", len(stats))
+	}
+	if stats[0].ID != -1 {
+		t.Fatalf("Expecting first stat ID -1, got %d", stats[0].ID)
+	}
+
+	// Permuting the most important feature should increase the OOB
+	// error of forest.
+	for _, stat := range stats[1:] {
+		if stat.ID == int64(imps[0].Index) &&
+			stat.OobError <= stats[0].OobError {
+			t.Fatalf("Expecting OOB error greater than %f, got %f",
+				stats[0].OobError, stat.OobError)
+		}
+	}
+
+	// The same seed should give the same importances.
+	got, _, _, _ := forest.PermutationImportances(&samples)
+	if !reflect.DeepEqual(imps, got) {
+		t.Fatalf("Expecting importances %v, got %v", imps, got)
+	}
+
+	loaded := rf.Runtime{}
+	buf := bytes.Buffer{}
+
+	_ = forest.Save(&buf, false)
+	_ = loaded.Load(&buf)
+	loaded.RunOOB = true
+
+	_, _, _, e = loaded.PermutationImportances(&samples)
+	if e != rf.ErrNoBag {
+		t.Fatalf("Expecting error %v, got %v", rf.ErrNoBag, e)
+	}
+}
+
+func TestProximity(t *testing.T) {
+	samples := tabula.Claset{}
+	_, e := dsv.SimpleRead("../../testdata/iris/iris.dsv", &samples)
+	if nil != e {
+		t.Fatal(e)
+	}
+
+	forest := rf.Runtime{
+		Runtime: classifier.Runtime{
+			OOBStatsFile: "iris.prox.oob",
+		},
+		NTree: 20,
+		Seed:  5,
+	}
+
+	e = forest.Build(&samples)
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	nrow := samples.GetNRow()
+
+	prox, e := forest.Proximity(&samples, 0, false)
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	for x := 0; x < nrow; x++ {
+		for _, nb := range prox.Neighbors[x] {
+			if nb.Value <= 0 || nb.Value > 1 {
+				t.Fatalf("Expecting proximity in (0,1], got %v", nb)
+			}
+			if prox.At(nb.Index, x) != nb.Value {
+				t.Fatalf("Expecting symmetric proximity %d,%d",
+					x, nb.Index)
+			}
+		}
+	}
+
+	topk, e := forest.Proximity(&samples, 5, true)
+	if e != nil {
+		t.Fatal(e)
+	}
+	for x := 0; x < nrow; x++ {
+		if len(topk.Neighbors[x]) > 5 {
+			t.Fatalf("Expecting at most 5 neighbors, got %d",
+				len(topk.Neighbors[x]))
+		}
+	}
+
+	// Plant an outlier by labeling the first setosa sample as virginica,
+	// which never fall in the same leaf with setosa samples.
+	actuals := samples.GetClassAsStrings()
+	actuals[0] = "Iris-virginica"
+
+	scores := prox.Outliers(actuals)
+	if len(scores) != nrow {
+		t.Fatalf("Expecting %d outlier scores, got %d", nrow,
+			len(scores))
+	}
+	if scores[0] <= 10 {
+		t.Fatalf("Expecting outlier score greater than 10, got %f",
+			scores[0])
+	}
+	for x := range scores {
+		if actuals[x] == actuals[0] && scores[x] > scores[0] {
+			t.Fatalf("Expecting highest outlier score %f, got %f"+
+				" on sample %d", scores[0], scores[x], x)
+		}
+	}
+
+	// Prototype of each class should follow their petal length, where
+	// setosa petal is always shorter than 2.
+	vs := samples.GetClassValueSpace()
+	protos := prox.Prototypes(&samples, 10)
+	if len(protos) != 3 {
+		t.Fatalf("Expecting 3 prototypes, got %d", len(protos))
+	}
+
+	petals := make([]float64, len(protos))
+	for x, proto := range protos {
+		if (*proto)[4].String() != vs[x] {
+			t.Fatalf("Expecting prototype of %s, got %v", vs[x],
+				proto)
+		}
+		petals[x] = (*proto)[2].Float()
+	}
+	if petals[0] >= 2 || petals[0] >= petals[1] || petals[1] >= petals[2] {
+		t.Fatalf("Expecting ordered petal length, got %v", petals)
+	}
+
+	// Setosa samples should be closer to each other than to other
+	// samples in MDS coordinates.
+	coords := prox.MDS(2)
+	if len(coords) != nrow || len(coords[0]) != 2 {
+		t.Fatalf("Expecting %d coordinates in 2 dimension", nrow)
+	}
+
+	actuals = samples.GetClassAsStrings()
+	inner, outer := mdsDistances(coords, actuals, vs[0])
+	if inner >= outer {
+		t.Fatalf("Expecting setosa distance %f less than %f", inner,
+			outer)
+	}
+
+	for _, coord := range topk.MDS(2) {
+		if math.IsNaN(coord[0]) || math.IsNaN(coord[1]) {
+			t.Fatalf("Expecting coordinates on top-k proximity,"+
+				" got %v", coord)
+		}
+	}
+}
+
+//
+// mdsDistances return the mean distance between samples of `class`, and the
+// mean distance between samples of `class` and other samples.
+//
+func mdsDistances(coords [][]float64, actuals []string, class string) (
+	inner, outer float64,
+) {
+	var ninner, nouter int
+
+	for x := range coords {
+		if actuals[x] != class {
+			continue
+		}
+		for y := range coords {
+			if y == x {
+				continue
+			}
+
+			d := math.Hypot(coords[x][0]-coords[y][0],
+				coords[x][1]-coords[y][1])
+
+			if actuals[y] == class {
+				inner += d
+				ninner++
+			} else {
+				outer += d
+				nouter++
+			}
+		}
+	}
+
+	return inner / float64(ninner), outer / float64(nouter)
+}
+
+func TestSamplingMethod(t *testing.T) {
+	samples := tabula.Claset{}
+	_, e := dsv.SimpleRead("../../testdata/iris/iris.dsv", &samples)
+	if nil != e {
+		t.Fatal(e)
+	}
+
+	actuals := samples.GetClassAsStrings()
+
+	cases := []struct {
+		method string
+		sizes  map[string]int
+		exp    map[string]int
+		expErr error
+	}{{
+		method: rf.SamplingStratified,
+		exp: map[string]int{
+			"Iris-setosa":     33,
+			"Iris-versicolor": 33,
+			"Iris-virginica":  33,
+		},
+	}, {
+		method: rf.SamplingBalanced,
+		sizes: map[string]int{
+			"Iris-setosa": 5,
+		},
+		exp: map[string]int{
+			"Iris-setosa":     5,
+			"Iris-versicolor": 50,
+			"Iris-virginica":  50,
+		},
+	}, {
+		method: "unknown",
+		expErr: rf.ErrSamplingMethod,
+	}, {
+		sizes: map[string]int{
+			"Iris-unknown": 5,
+		},
+		expErr: rf.ErrSampleSizes,
+	}}
+
+	for _, c := range cases {
+		forest := rf.Runtime{
+			Runtime: classifier.Runtime{
+				OOBStatsFile: "iris.sampling.oob",
+			},
+			NTree:          5,
+			SamplingMethod: c.method,
+			SampleSizes:    c.sizes,
+		}
+
+		e = forest.Build(&samples)
+		if e != c.expErr {
+			t.Fatalf("%s: expecting error %v, got %v", c.method,
+				c.expErr, e)
+		}
+		if e != nil {
+			continue
+		}
+
+		// The bag only contain unique samples, so the number of
+		// samples in each class is at most the sample size.
+		for _, bag := range forest.Bags() {
+			got := make(map[string]int)
+			for _, idx := range bag.Indices() {
+				got[actuals[idx]]++
+			}
+
+			for class, size := range c.exp {
+				if got[class] == 0 || got[class] > size {
+					t.Fatalf("%s: expecting %d %s, got %d",
+						c.method, size, class,
+						got[class])
+				}
+			}
+		}
+	}
+}
+
+func TestSamplingTinyClass(t *testing.T) {
+	samples := tabula.Claset{}
+	_, e := dsv.SimpleRead("../../testdata/iris/iris_tiny.dsv", &samples)
+	if nil != e {
+		t.Fatal(e)
+	}
+
+	forest := rf.Runtime{
+		Runtime: classifier.Runtime{
+			OOBStatsFile: "iris_tiny.sampling.oob",
+		},
+		NTree:          5,
+		SamplingMethod: rf.SamplingStratified,
+	}
+
+	e = forest.Build(&samples)
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	// The only setosa sample is at index 0, and its stratum size should
+	// be at least one, so it is picked by every tree.
+	for x, bag := range forest.Bags() {
+		if !bag.Has(0) {
+			t.Fatalf("Expecting setosa in bag of tree %d", x)
+		}
+	}
+}
@@ -0,0 +1,285 @@
+// Copyright 2016 Mhd Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rf
+
+import (
+	"errors"
+	"github.com/shuLhan/tabula"
+	"math/rand"
+	"sort"
+)
+
+const (
+	// SamplingRandom pick the samples randomly from all samples.
+	SamplingRandom = "random"
+	// SamplingStratified pick the samples randomly from each class,
+	// where number of samples from each class is proportional to their
+	// number in all samples.
+	SamplingStratified = "stratified"
+	// SamplingBalanced pick the same number of samples from each class,
+	// which is the number of samples in the smallest class, as in
+	// balanced random forest by Chen, Liaw, and Breiman.
+	SamplingBalanced = "balanced"
+	// SamplingNone use all samples to build each tree, without
+	// bootstraping. Since there is no out-of-bag samples, the OOB will not
+	// be computed.
+	SamplingNone = "none"
+)
+
+var (
+	// ErrSamplingMethod will be returned when the sampling method is
+	// unknown.
+	ErrSamplingMethod = errors.New("rf: unknown sampling method")
+	// ErrSampleSizes will be returned when the class in sample sizes is
+	// not exist in samples, or its size is negative.
+	ErrSampleSizes = errors.New("rf: invalid class in sample sizes")
+)
+
+/*
+initSampling will divide the samples into strata and compute number of
+samples that will be picked in each stratum, based on SamplingMethod and
+SampleSizes.
+
+Random sampling without SampleSizes use one stratum which contain all
+samples, otherwise each class is one stratum.
+On stratified sampling, or random sampling with SampleSizes, the size of
+class that is not defined in SampleSizes is computed by stratifiedSizes.
+*/
+func (forest *Runtime) initSampling(samples tabula.ClasetInterface) error {
+	switch forest.SamplingMethod {
+	case "":
+		forest.SamplingMethod = SamplingRandom
+	case SamplingRandom, SamplingStratified, SamplingBalanced:
+	case SamplingNone:
+		forest.RunOOB = false
+		forest.strata = nil
+		forest.strataSizes = nil
+		return nil
+	default:
+		return ErrSamplingMethod
+	}
+
+	if forest.Regression && (forest.SamplingMethod != SamplingRandom ||
+		len(forest.SampleSizes) > 0) {
+		return ErrSamplingRegression
+	}
+
+	nrow := samples.GetNRow()
+
+	if forest.SamplingMethod == SamplingRandom &&
+		len(forest.SampleSizes) == 0 {
+		all := make([]int, nrow)
+		for x := range all {
+			all[x] = x
+		}
+		forest.strata = [][]int{all}
+		forest.strataSizes = []int{forest.nSubsample}
+		return nil
+	}
+
+	vs := samples.GetClassValueSpace()
+	actuals := samples.GetClassAsStrings()
+
+	for class, size := range forest.SampleSizes {
+		found := false
+		for _, v := range vs {
+			if v == class {
+				found = true
+				break
+			}
+		}
+		if !found || size < 0 {
+			return ErrSampleSizes
+		}
+	}
+
+	classRows := make(map[string][]int, len(vs))
+	for x, class := range actuals {
+		classRows[class] = append(classRows[class], x)
+	}
+
+	minority := -1
+	for _, class := range vs {
+		n := len(classRows[class])
+		if n > 0 && (minority < 0 || n < minority) {
+			minority = n
+		}
+	}
+
+	forest.strata = nil
+	forest.strataSizes = nil
+
+	var propIdx, propCounts []int
+
+	for _, class := range vs {
+		rows := classRows[class]
+		if len(rows) == 0 {
+			continue
+		}
+
+		size, ok := forest.SampleSizes[class]
+		if !ok {
+			switch forest.SamplingMethod {
+			case SamplingRandom, SamplingStratified:
+				propIdx = append(propIdx, len(forest.strata))
+				propCounts = append(propCounts, len(rows))
+			case SamplingBalanced:
+				size = minority
+			}
+		}
+
+		forest.strata = append(forest.strata, rows)
+		forest.strataSizes = append(forest.strataSizes, size)
+	}
+
+	sizes := stratifiedSizes(propCounts, forest.nSubsample, nrow)
+	for x, idx := range propIdx {
+		forest.strataSizes[idx] = sizes[x]
+	}
+
+	return nil
+}
+
+//
+// stratifiedSizes return number of samples that is picked from each
+// stratum, where `counts` is number of samples in each stratum, proportional
+// to `nsubsample` picked from all `nrow` samples.
+//
+// The size is rounded using the largest remainder method, so the total size
+// is equal to the proportion of `nsubsample`, but each non-empty stratum will
+// have at least one sample.
+//
+func stratifiedSizes(counts []int, nsubsample, nrow int) (sizes []int) {
+	if len(counts) == 0 || nrow <= 0 {
+		return nil
+	}
+
+	total := 0
+	for _, n := range counts {
+		total += n
+	}
+
+	target := int(float64(total)*float64(nsubsample)/float64(nrow) + 0.5)
+
+	sizes = make([]int, len(counts))
+	remainders := make([]float64, len(counts))
+	order := make([]int, len(counts))
+	sum := 0
+
+	for x, n := range counts {
+		quota := float64(n) * float64(nsubsample) / float64(nrow)
+
+		sizes[x] = int(quota)
+		if sizes[x] == 0 && n > 0 {
+			sizes[x] = 1
+		}
+
+		remainders[x] = quota - float64(sizes[x])
+		order[x] = x
+		sum += sizes[x]
+	}
+
+	// Order the strata by their remainder, from the largest.
+	sort.Stable(byRemainder{order, remainders})
+
+	for x := 0; sum < target; x = (x + 1) % len(order) {
+		sizes[order[x]]++
+		sum++
+	}
+
+	// The minimum size may exceed the target, take it back from the
+	// stratum with the smallest remainder.
+	for x := len(order) - 1; sum > target && x >= 0; x-- {
+		if sizes[order[x]] > 1 {
+			sizes[order[x]]--
+			sum--
+		}
+	}
+
+	return sizes
+}
+
+//
+// byRemainder sort the index of strata in `order` by their remainder in
+// descending order.
+//
+type byRemainder struct {
+	order      []int
+	remainders []float64
+}
+
+func (br byRemainder) Len() int {
+	return len(br.order)
+}
+
+func (br byRemainder) Less(i, j int) bool {
+	return br.remainders[br.order[i]] > br.remainders[br.order[j]]
+}
+
+func (br byRemainder) Swap(i, j int) {
+	br.order[i], br.order[j] = br.order[j], br.order[i]
+}
+
+//
+// bootstrap will pick random rows from each stratum in `samples` with
+// replacement using random source `rnd`, or all rows if SamplingMethod is
+// SamplingNone.
+// It will return the picked rows as `bag`, the rows that are not picked as
+// `oob`, and their index in `samples`.
+//
+func (forest *Runtime) bootstrap(samples tabula.ClasetInterface,
+	rnd *rand.Rand,
+) (
+	bag, oob tabula.ClasetInterface, bagIdx, oobIdx []int,
+) {
+	if forest.SamplingMethod == SamplingNone {
+		bagIdx = make([]int, samples.GetNRow())
+		for x := range bagIdx {
+			bagIdx[x] = x
+		}
+		return pickRows(samples, bagIdx), pickRows(samples, nil),
+			bagIdx, nil
+	}
+
+	picked := make([]bool, samples.GetNRow())
+
+	for x, rows := range forest.strata {
+		for y := 0; y < forest.strataSizes[x]; y++ {
+			idx := rows[rnd.Intn(len(rows))]
+			bagIdx = append(bagIdx, idx)
+			picked[idx] = true
+		}
+	}
+
+	for idx, isPicked := range picked {
+		if !isPicked {
+			oobIdx = append(oobIdx, idx)
+		}
+	}
+
+	sort.Ints(bagIdx)
+
+	bag = pickRows(samples, bagIdx)
+	oob = pickRows(samples, oobIdx)
+
+	return bag, oob, bagIdx, oobIdx
+}
+
+//
+// pickRows return new dataset which contain rows in `samples` at index
+// `idx`.
+//
+func pickRows(samples tabula.ClasetInterface, idx []int) (
+	picked tabula.ClasetInterface,
+) {
+	picked = samples.Clone().(tabula.ClasetInterface)
+	picked.SetClassIndex(samples.GetClassIndex())
+
+	for _, x := range idx {
+		picked.PushRow(samples.GetRow(x))
+	}
+
+	return picked
+}
@@ -0,0 +1,94 @@
+// Copyright 2016 Mhd Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rf
+
+const (
+	// DefStopWindow default number of trees where the OOB error is
+	// checked for convergence.
+	DefStopWindow = 10
+
+	// StopReasonNTree is the stop reason when forest has grown NTree
+	// trees.
+	StopReasonNTree = "ntree"
+	// StopReasonConverged is the stop reason when the mean of OOB error
+	// in the last StopWindow trees has converged.
+	StopReasonConverged = "converged"
+)
+
+//
+// initStopping will set the early stopping parameters to their default
+// values, if early stopping is enabled and the parameters is not set.
+//
+func (forest *Runtime) initStopping() {
+	if forest.StopEpsilon <= 0 {
+		return
+	}
+	if forest.StopWindow <= 0 {
+		forest.StopWindow = DefStopWindow
+	}
+	if forest.MinTree <= 0 {
+		forest.MinTree = forest.StopWindow
+	}
+}
+
+//
+// isConverged will return true if early stopping is enabled, the forest has
+// at least MinTree trees, and the difference between the highest and lowest
+// mean of OOB error in the last StopWindow trees is less than StopEpsilon.
+// On regression forest, the OOB error is the mean squared error, so
+// StopEpsilon should be set on the same scale as the class attribute.
+//
+// Early stopping require the OOB error, so it will always return false if
+// RunOOB is false.
+//
+func (forest *Runtime) isConverged() bool {
+	if forest.StopEpsilon <= 0 || !forest.RunOOB {
+		return false
+	}
+	if len(forest.trees) < forest.MinTree {
+		return false
+	}
+
+	means := forest.oobErrorMeans()
+	if len(means) < forest.StopWindow {
+		return false
+	}
+
+	means = means[len(means)-forest.StopWindow:]
+	min := means[0]
+	max := means[0]
+
+	for _, v := range means[1:] {
+		if v < min {
+			min = v
+		}
+		if v > max {
+			max = v
+		}
+	}
+
+	return max-min < forest.StopEpsilon
+}
+
+//
+// oobErrorMeans return the moving mean of OOB error after each tree, which
+// is the running mean of mean squared error on regression forest.
+//
+func (forest *Runtime) oobErrorMeans() (means []float64) {
+	if !forest.Regression {
+		return forest.OOBStats().OobErrorMeans()
+	}
+
+	mses := forest.OOBRegressionStats().MSEs()
+	means = make([]float64, len(mses))
+	sum := 0.0
+
+	for x, mse := range mses {
+		sum += mse
+		means[x] = sum / float64(x+1)
+	}
+
+	return means
+}
@@ -52,6 +52,13 @@ type Runtime struct {
 	// oobStatTotal contain total OOB statistic values.
 	oobStatTotal Stat
 
+	// oobRegStats contain regression statistic for each OOB in
+	// iteration.
+	oobRegStats RegressionStats
+
+	// oobRegStatTotal contain total OOB regression statistic values.
+	oobRegStatTotal RegressionStat
+
 	// oobWriter contain file writer for statistic.
 	oobWriter *dsv.Writer
 
@@ -74,10 +81,33 @@ func init() {
 //
 func (rt *Runtime) Initialize() error {
 	rt.oobStatTotal.Start()
+	rt.oobRegStatTotal.Start()
 
 	return rt.OpenOOBStatsFile()
 }
 
+//
+// Resume will continue the runtime that has been finalized before, e.g. to
+// add more trees into forest, by opening the stats file for appending
+// instead of truncating it.
+// The statistic of previous run is kept, so the total statistic is continued
+// from the last statistic.
+//
+func (rt *Runtime) Resume() error {
+	if rt.oobStatTotal.StartTime == 0 {
+		rt.oobStatTotal.Start()
+	}
+	if rt.oobRegStatTotal.StartTime == 0 {
+		rt.oobRegStatTotal.Start()
+	}
+
+	if rt.oobWriter != nil {
+		_ = rt.CloseOOBStatsFile()
+	}
+	rt.oobWriter = &dsv.Writer{}
+	return rt.oobWriter.ReopenOutput(rt.OOBStatsFile)
+}
+
 //
 // Finalize finish the runtime, compute total statistic, write it to file, and
 // close the file.
@@ -96,6 +126,26 @@ func (rt *Runtime) Finalize() (e error) {
 	return rt.CloseOOBStatsFile()
 }
 
+//
+// FinalizeRegression finish the runtime of regression, write the total
+// regression statistic to file, and close the file.
+// The total statistic should be computed by the regression before calling
+// this method.
+//
+func (rt *Runtime) FinalizeRegression() (e error) {
+	st := &rt.oobRegStatTotal
+
+	st.End()
+	st.ID = int64(len(rt.oobRegStats))
+
+	e = rt.WriteOOBRegressionStat(st)
+	if e != nil {
+		return e
+	}
+
+	return rt.CloseOOBStatsFile()
+}
+
 //
 // OOBStats return all statistic objects.
 //
@@ -110,6 +160,49 @@ func (rt *Runtime) StatTotal() *Stat {
 	return &rt.oobStatTotal
 }
 
+//
+// OOBRegressionStats return all regression statistic objects.
+//
+func (rt *Runtime) OOBRegressionStats() *RegressionStats {
+	return &rt.oobRegStats
+}
+
+//
+// RegressionStatTotal return total regression statistic.
+//
+func (rt *Runtime) RegressionStatTotal() *RegressionStat {
+	return &rt.oobRegStatTotal
+}
+
+//
+// SetOOBStats will replace all statistic objects, e.g. with the statistic
+// of classifier that is loaded from file.
+//
+func (rt *Runtime) SetOOBStats(stats Stats) {
+	rt.oobStats = stats
+}
+
+//
+// SetStatTotal will replace total statistic.
+//
+func (rt *Runtime) SetStatTotal(stat Stat) {
+	rt.oobStatTotal = stat
+}
+
+//
+// SetOOBRegressionStats will replace all regression statistic objects.
+//
+func (rt *Runtime) SetOOBRegressionStats(stats RegressionStats) {
+	rt.oobRegStats = stats
+}
+
+//
+// SetRegressionStatTotal will replace total regression statistic.
+//
+func (rt *Runtime) SetRegressionStatTotal(stat RegressionStat) {
+	rt.oobRegStatTotal = stat
+}
+
 //
 // AddOOBCM will append new confusion matrix.
 //
@@ -124,6 +217,13 @@ func (rt *Runtime) AddStat(stat *Stat) {
 	rt.oobStats = append(rt.oobStats, stat)
 }
 
+//
+// AddRegressionStat will append new regression statistic data.
+//
+func (rt *Runtime) AddRegressionStat(stat *RegressionStat) {
+	rt.oobRegStats = append(rt.oobRegStats, stat)
+}
+
 //
 // ComputeCM will compute confusion matrix of sample using value space, actual
 // and prediction values.
@@ -297,6 +397,19 @@ func (rt *Runtime) WriteOOBStat(stat *Stat) error {
 	return rt.oobWriter.WriteRawRow(stat.ToRow(), nil, nil)
 }
 
+//
+// WriteOOBRegressionStat will write regression statistic of process to file.
+//
+func (rt *Runtime) WriteOOBRegressionStat(stat *RegressionStat) error {
+	if rt.oobWriter == nil {
+		return nil
+	}
+	if stat == nil {
+		return nil
+	}
+	return rt.oobWriter.WriteRawRow(stat.ToRow(), nil, nil)
+}
+
 //
 // CloseOOBStatsFile will close statistics file for writing.
 //
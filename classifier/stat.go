@@ -52,6 +52,10 @@ type Stat struct {
 	Accuracy float64
 	// AUC contain the area under curve.
 	AUC float64
+	// StopReason contain the reason why the classifier stop training,
+	// e.g. the maximum number of trees has been reached, or the OOB error
+	// has converged. It is only set on total statistic.
+	StopReason string
 }
 
 // SetAUC will set the AUC value.
@@ -112,6 +116,7 @@ func (stat *Stat) Sum(other *Stat) {
 
 //
 // ToRow will convert the stat to tabula.row in the order of Stat field.
+// The StopReason is added only if its not empty.
 //
 func (stat *Stat) ToRow() (row *tabula.Row) {
 	row = &tabula.Row{}
@@ -134,6 +139,10 @@ func (stat *Stat) ToRow() (row *tabula.Row) {
 	row.PushBack(tabula.NewRecordReal(stat.Accuracy))
 	row.PushBack(tabula.NewRecordReal(stat.AUC))
 
+	if stat.StopReason != "" {
+		row.PushBack(tabula.NewRecordString(stat.StopReason))
+	}
+
 	return
 }
 
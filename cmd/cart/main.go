@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"github.com/shuLhan/dsv"
 	"github.com/shuLhan/go-mining/classifier/cart"
+	"github.com/shuLhan/go-mining/math"
 	"github.com/shuLhan/tabula"
 	"io/ioutil"
 	"os"
@@ -21,11 +22,35 @@ var (
 	// DEBUG level, can be set from environment variable.
 	DEBUG          = 0
 	nRandomFeature = 0
+	// pruneMethod if its not empty, overwrite the PruneMethod in config.
+	pruneMethod = ""
+	// saveFile if its not empty, the tree will be saved to this file.
+	saveFile = ""
+	// testCfg point to the configuration file for testing regression
+	// tree.
+	testCfg = ""
+	// importanceFile if its not empty, the ranked feature importance will
+	// be written to this file.
+	importanceFile = ""
+	// dotFile if its not empty, the tree will be written to this file in
+	// Graphviz DOT format.
+	dotFile = ""
+	// rulesFile if its not empty, the rules from tree will be written to
+	// this file.
+	rulesFile = ""
+	// rulesFormat define the format of rules, its either "text" or
+	// "json".
+	rulesFormat = "text"
+	// simplify if its true, the conditions in rules will be simplified.
+	simplify = false
 )
 
 var usage = func() {
 	cmd := os.Args[0]
-	fmt.Fprintf(os.Stderr, "Usage of %s: [-n number] [config.dsv]\n", cmd)
+	fmt.Fprintf(os.Stderr, "Usage of %s: [-n number] [-prune method] [-save file]"+
+		" [-test config.dsv] [-importance file] [-dot file]"+
+		" [-rules file] [-rulesformat text|json] [-simplify]"+
+		" [config.dsv]\n", cmd)
 	flag.PrintDefaults()
 }
 
@@ -39,9 +64,26 @@ func init() {
 
 	flagUsage := []string{
 		"Number of random feature (default 0)",
+		"Save the tree into file",
+		"Prune the tree using method: holdout or cv (default none)",
+		"Test configuration, for reporting RMSE and MAE on regression" +
+			" tree (default to training configuration)",
+		"Write the ranked feature importance into file",
+		"Write the tree into file using Graphviz DOT format",
+		"Write the rules from tree into file",
+		"Format of rules: text or json (default text)",
+		"Simplify the conditions in rules",
 	}
 
 	flag.IntVar(&nRandomFeature, "n", 0, flagUsage[0])
+	flag.StringVar(&saveFile, "save", "", flagUsage[1])
+	flag.StringVar(&pruneMethod, "prune", "", flagUsage[2])
+	flag.StringVar(&testCfg, "test", "", flagUsage[3])
+	flag.StringVar(&importanceFile, "importance", "", flagUsage[4])
+	flag.StringVar(&dotFile, "dot", "", flagUsage[5])
+	flag.StringVar(&rulesFile, "rules", "", flagUsage[6])
+	flag.StringVar(&rulesFormat, "rulesformat", "text", flagUsage[7])
+	flag.BoolVar(&simplify, "simplify", false, flagUsage[8])
 }
 
 func trace(s string) (string, time.Time) {
@@ -71,10 +113,102 @@ func createCart(fcfg string) (*cart.Runtime, error) {
 	if nRandomFeature > 0 {
 		cartrt.NRandomFeature = nRandomFeature
 	}
+	if pruneMethod != "" {
+		cartrt.PruneMethod = pruneMethod
+	}
 
 	return cartrt, nil
 }
 
+//
+// saveCart will write the tree into file.
+//
+func saveCart(cartrt *cart.Runtime, file string) (e error) {
+	f, e := os.Create(file)
+	if e != nil {
+		return e
+	}
+
+	e = cartrt.Save(f)
+	if e != nil {
+		_ = f.Close()
+		return e
+	}
+
+	return f.Close()
+}
+
+//
+// writeDOT will write the tree into file in Graphviz DOT format.
+//
+func writeDOT(cartrt *cart.Runtime, file string) (e error) {
+	f, e := os.Create(file)
+	if e != nil {
+		return e
+	}
+
+	e = cartrt.WriteDOT(f)
+	if e != nil {
+		_ = f.Close()
+		return e
+	}
+
+	return f.Close()
+}
+
+//
+// writeRules will write the rules from tree into file, using text or JSON
+// format.
+//
+func writeRules(cartrt *cart.Runtime, file, format string) (e error) {
+	rules := cartrt.Rules(simplify)
+
+	var out []byte
+
+	switch format {
+	case "json":
+		out, e = json.MarshalIndent(rules, "", "\t")
+		if e != nil {
+			return e
+		}
+		out = append(out, '\n')
+	case "text":
+		for x := range rules {
+			out = append(out, rules[x].String()...)
+			out = append(out, '\n')
+		}
+	default:
+		return fmt.Errorf("[cart] unknown rules format %q", format)
+	}
+
+	return ioutil.WriteFile(file, out, 0644)
+}
+
+//
+// testRegression will predict the samples in `fcfg` using regression tree
+// and print the root mean squared error and mean absolute error.
+//
+func testRegression(cartrt *cart.Runtime, fcfg string) (e error) {
+	testset := tabula.Claset{}
+	_, e = dsv.SimpleRead(fcfg, &testset)
+	if e != nil {
+		return e
+	}
+
+	actuals := testset.GetClassAsReals()
+	predicts := make([]float64, len(actuals))
+
+	rows := testset.GetRows()
+	for x, row := range *rows {
+		predicts[x] = cartrt.Predict(row)
+	}
+
+	fmt.Printf("[cart] RMSE: %f, MAE: %f\n", math.RMSE(actuals, predicts),
+		math.MAE(actuals, predicts))
+
+	return nil
+}
+
 func main() {
 	defer un(trace("cart"))
 
@@ -112,4 +246,43 @@ func main() {
 	if DEBUG >= 1 {
 		fmt.Println("[cart] CART tree:\n", cartrt)
 	}
+
+	if cartrt.IsRegression() {
+		if testCfg == "" {
+			testCfg = fcfg
+		}
+
+		e = testRegression(cartrt, testCfg)
+		if e != nil {
+			panic(e)
+		}
+	}
+
+	if saveFile != "" {
+		e = saveCart(cartrt, saveFile)
+		if e != nil {
+			panic(e)
+		}
+	}
+
+	if importanceFile != "" {
+		e = cartrt.FeatureImportances().Write(importanceFile)
+		if e != nil {
+			panic(e)
+		}
+	}
+
+	if dotFile != "" {
+		e = writeDOT(cartrt, dotFile)
+		if e != nil {
+			panic(e)
+		}
+	}
+
+	if rulesFile != "" {
+		e = writeRules(cartrt, rulesFile, rulesFormat)
+		if e != nil {
+			panic(e)
+		}
+	}
 }
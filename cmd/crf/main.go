@@ -9,6 +9,7 @@ import (
 	"flag"
 	"fmt"
 	"github.com/shuLhan/dsv"
+	"github.com/shuLhan/go-mining/classifier"
 	"github.com/shuLhan/go-mining/classifier/crf"
 	"github.com/shuLhan/tabula"
 	"io/ioutil"
@@ -41,6 +42,9 @@ var (
 	trainCfg = ""
 	// testCfg point to the configuration file for testing
 	testCfg = ""
+	// predictionsFile if its not empty, the prediction and probabilities
+	// of each class for each test sample will be written to this file.
+	predictionsFile = ""
 
 	// crforest the main object.
 	crforest crf.Runtime
@@ -66,6 +70,7 @@ func init() {
 		"Performance file, where statistic of classifying data set will be written",
 		"Training configuration",
 		"Test configuration",
+		"Write the prediction and class probabilities of test set into file",
 	}
 
 	flag.IntVar(&nStage, "nstage", -1, flagUsage[0])
@@ -78,6 +83,7 @@ func init() {
 
 	flag.StringVar(&trainCfg, "train", "", flagUsage[6])
 	flag.StringVar(&testCfg, "test", "", flagUsage[7])
+	flag.StringVar(&predictionsFile, "predictions", "", flagUsage[8])
 }
 
 func trace() (start time.Time) {
@@ -165,11 +171,19 @@ func test() {
 	fmt.Println(tag, "Test set:", &testset)
 	fmt.Println(tag, "Sample test set:", testset.GetRow(0))
 
-	predicts, cm, probs := crforest.ClassifySetByWeight(&testset, nil)
+	predicts, cm, classProbs := crforest.ClassifySetByWeightProba(&testset,
+		nil)
 
 	fmt.Println("[crf] Test set CM:", cm)
 
-	crforest.Performance(&testset, predicts, probs)
+	e = classifier.WritePredictions(predictionsFile,
+		testset.GetClassAsStrings(), predicts, classProbs)
+	if e != nil {
+		panic(e)
+	}
+
+	crforest.Performance(&testset, predicts,
+		classifier.FirstProbs(classProbs))
 
 	e = crforest.WritePerformance()
 	if e != nil {
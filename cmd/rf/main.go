@@ -9,6 +9,7 @@ import (
 	"flag"
 	"fmt"
 	"github.com/shuLhan/dsv"
+	"github.com/shuLhan/go-mining/classifier"
 	"github.com/shuLhan/go-mining/classifier/rf"
 	"github.com/shuLhan/tabula"
 	"io/ioutil"
@@ -30,6 +31,18 @@ var (
 	nRandomFeature = 0
 	// percentBoot percentage of sample for bootstraping.
 	percentBoot = 0
+	// nWorkers number of trees that are grown concurrently.
+	nWorkers = 0
+	// seed for random number generator.
+	seed int64
+	// stopEpsilon if its greater than zero, stop growing the trees when
+	// the mean of OOB error has converged.
+	stopEpsilon = 0.0
+	// stopWindow number of last trees where the mean of OOB error is
+	// checked.
+	stopWindow = 0
+	// minTree minimum number of trees before early stopping.
+	minTree = 0
 	// oobStatsFile where statistic will be written.
 	oobStatsFile = ""
 	// perfFile where performance of classifier will be written.
@@ -39,9 +52,33 @@ var (
 	trainCfg = ""
 	// testCfg point to the configuration file for testing
 	testCfg = ""
+	// saveFile if its not empty, the trained forest will be saved to this
+	// file.
+	saveFile = ""
+	// modelFile point to the saved forest that will be used for testing.
+	modelFile = ""
+	// importanceFile if its not empty, the ranked feature importance will
+	// be written to this file.
+	importanceFile = ""
+	// permImportanceFile if its not empty, the ranked permutation
+	// importance of features will be written to this file, the importance
+	// in each class will be written to the same file with class value as
+	// suffix, and the OOB statistic of forest after permuting each feature
+	// will be written to the same file with ".stat" suffix.
+	permImportanceFile = ""
+	// dotFile if its not empty, one of tree in forest will be written to
+	// this file in Graphviz DOT format.
+	dotFile = ""
+	// dotTree index of tree in forest that will be written to dotFile.
+	dotTree = 0
+	// predictionsFile if its not empty, the prediction and probabilities
+	// of each class for each test sample will be written to this file.
+	predictionsFile = ""
 
 	// forest the main object.
 	forest rf.Runtime
+	// trainset contain the samples for training the forest.
+	trainset tabula.Claset
 )
 
 var usage = func() {
@@ -63,6 +100,18 @@ func init() {
 		"Performance file, where statistic of classifying data set will be written",
 		"Training configuration",
 		"Test configuration",
+		"Save the trained forest into file",
+		"Load the forest from file, instead of training; with -train, continue growing the loaded forest until -ntree trees",
+		"Write the ranked feature importance into file",
+		"Write one of tree into file using Graphviz DOT format",
+		"Index of tree that will be written by -dot (default 0)",
+		"Number of trees grown concurrently (default number of CPU)",
+		"Seed for random number generator (default current time)",
+		"Write the ranked permutation importance into file (require -train with RunOOB)",
+		"Write the prediction and class probabilities of test set into file",
+		"Stop growing trees when the mean of OOB error in the last -stopwindow trees changes less than this value (default 0, disabled)",
+		"Number of last trees where OOB error is checked for early stopping (default 10)",
+		"Minimum number of trees before early stopping (default -stopwindow)",
 	}
 
 	flag.IntVar(&nTree, "ntree", -1, flagUsage[0])
@@ -74,6 +123,18 @@ func init() {
 
 	flag.StringVar(&trainCfg, "train", "", flagUsage[5])
 	flag.StringVar(&testCfg, "test", "", flagUsage[6])
+	flag.StringVar(&saveFile, "save", "", flagUsage[7])
+	flag.StringVar(&modelFile, "model", "", flagUsage[8])
+	flag.StringVar(&importanceFile, "importance", "", flagUsage[9])
+	flag.StringVar(&dotFile, "dot", "", flagUsage[10])
+	flag.IntVar(&dotTree, "dottree", 0, flagUsage[11])
+	flag.IntVar(&nWorkers, "nworkers", -1, flagUsage[12])
+	flag.Int64Var(&seed, "seed", 0, flagUsage[13])
+	flag.StringVar(&permImportanceFile, "permimportance", "", flagUsage[14])
+	flag.StringVar(&predictionsFile, "predictions", "", flagUsage[15])
+	flag.Float64Var(&stopEpsilon, "stopepsilon", 0, flagUsage[16])
+	flag.IntVar(&stopWindow, "stopwindow", -1, flagUsage[17])
+	flag.IntVar(&minTree, "mintree", -1, flagUsage[18])
 }
 
 func trace() (start time.Time) {
@@ -91,6 +152,8 @@ func un(startTime time.Time) {
 // createRandomForest will create random forest for training, with the
 // following steps,
 // (1) load training configuration.
+// (1.1) If modelFile is set, load the saved trees, so the training will
+// continue growing the saved forest.
 // (2) Overwrite configuration parameter if its set from command line.
 //
 func createRandomForest() error {
@@ -107,6 +170,22 @@ func createRandomForest() error {
 		return e
 	}
 
+	// (1.1)
+	if modelFile != "" {
+		var f *os.File
+
+		f, e = os.Open(modelFile)
+		if e != nil {
+			return e
+		}
+
+		e = forest.Load(f)
+		_ = f.Close()
+		if e != nil {
+			return e
+		}
+	}
+
 	// (2)
 	if nTree > 0 {
 		forest.NTree = nTree
@@ -117,6 +196,21 @@ func createRandomForest() error {
 	if percentBoot > 0 {
 		forest.PercentBoot = percentBoot
 	}
+	if nWorkers > 0 {
+		forest.NWorkers = nWorkers
+	}
+	if seed != 0 {
+		forest.Seed = seed
+	}
+	if stopEpsilon > 0 {
+		forest.StopEpsilon = stopEpsilon
+	}
+	if stopWindow > 0 {
+		forest.StopWindow = stopWindow
+	}
+	if minTree > 0 {
+		forest.MinTree = minTree
+	}
 	if oobStatsFile != "" {
 		forest.OOBStatsFile = oobStatsFile
 	}
@@ -133,7 +227,7 @@ func train() {
 		panic(e)
 	}
 
-	trainset := tabula.Claset{}
+	trainset = tabula.Claset{}
 
 	_, e = dsv.SimpleRead(trainCfg, &trainset)
 	if e != nil {
@@ -146,6 +240,140 @@ func train() {
 	}
 }
 
+//
+// save will write the trained forest, including the bag indices, to
+// saveFile.
+//
+func save() {
+	f, e := os.Create(saveFile)
+	if e != nil {
+		panic(e)
+	}
+
+	e = forest.Save(f, true)
+	if e != nil {
+		_ = f.Close()
+		panic(e)
+	}
+
+	e = f.Close()
+	if e != nil {
+		panic(e)
+	}
+}
+
+//
+// load will read the forest from modelFile and set the output files from
+// command line parameters or to their default values.
+//
+func load() {
+	f, e := os.Open(modelFile)
+	if e != nil {
+		panic(e)
+	}
+
+	forest = rf.Runtime{}
+
+	e = forest.Load(f)
+	_ = f.Close()
+	if e != nil {
+		panic(e)
+	}
+
+	forest.PerfFile = rf.DefPerfFile
+	if perfFile != "" {
+		forest.PerfFile = perfFile
+	}
+	forest.StatFile = rf.DefStatFile
+}
+
+//
+// writeImportance will write the ranked feature importance in forest to
+// importanceFile.
+//
+func writeImportance() {
+	e := forest.FeatureImportances().Write(importanceFile)
+	if e != nil {
+		panic(e)
+	}
+}
+
+//
+// writePermImportance will write the ranked permutation importance of
+// features, using the out-of-bag samples in training set, to
+// permImportanceFile.
+//
+func writePermImportance() {
+	imps, classImps, stats, e := forest.PermutationImportances(&trainset)
+	if e != nil {
+		panic(e)
+	}
+
+	e = imps.Write(permImportanceFile)
+	if e != nil {
+		panic(e)
+	}
+
+	for class, classImp := range classImps {
+		e = classImp.Write(permImportanceFile + "." + class)
+		if e != nil {
+			panic(e)
+		}
+	}
+
+	e = stats.Write(permImportanceFile + ".stat")
+	if e != nil {
+		panic(e)
+	}
+}
+
+//
+// writeDOT will write the tree at index dotTree in forest to dotFile in
+// Graphviz DOT format.
+//
+func writeDOT() {
+	trees := forest.Trees()
+	if dotTree < 0 || dotTree >= len(trees) {
+		panic(fmt.Sprintf("%s tree index %d out of range [0,%d)", tag,
+			dotTree, len(trees)))
+	}
+
+	f, e := os.Create(dotFile)
+	if e != nil {
+		panic(e)
+	}
+
+	e = trees[dotTree].WriteDOT(f)
+	if e != nil {
+		_ = f.Close()
+		panic(e)
+	}
+
+	e = f.Close()
+	if e != nil {
+		panic(e)
+	}
+}
+
+//
+// regress will predict the value of each sample in test set using regression
+// forest, and write the predictions to predictionsFile if its set.
+//
+func regress(testset tabula.ClasetInterface) {
+	values, _ := forest.RegressSet(testset)
+
+	predicts := make([]string, len(values))
+	for x, v := range values {
+		predicts[x] = strconv.FormatFloat(v, 'f', -1, 64)
+	}
+
+	e := classifier.WritePredictions(predictionsFile,
+		testset.GetClassAsStrings(), predicts, nil)
+	if e != nil {
+		panic(e)
+	}
+}
+
 func test() {
 	testset := tabula.Claset{}
 	_, e := dsv.SimpleRead(testCfg, &testset)
@@ -153,9 +381,21 @@ func test() {
 		panic(e)
 	}
 
-	predicts, _, probs := forest.ClassifySet(&testset, nil)
+	if forest.Regression {
+		regress(&testset)
+		return
+	}
 
-	forest.Performance(&testset, predicts, probs)
+	predicts, _, classProbs := forest.ClassifySetProba(&testset, nil)
+
+	e = classifier.WritePredictions(predictionsFile,
+		testset.GetClassAsStrings(), predicts, classProbs)
+	if e != nil {
+		panic(e)
+	}
+
+	forest.Performance(&testset, predicts,
+		classifier.FirstProbs(classProbs))
 
 	e = forest.WritePerformance()
 	if e != nil {
@@ -166,10 +406,16 @@ func test() {
 //
 // (0) Parse and check command line parameters.
 // (1) If trainCfg parameter is set,
-// (1.1) train the model,
-// (1.2) TODO: load saved model.
-// (2) If testCfg parameter is set,
-// (2.1) Test the model using data from testCfg.
+// (1.1) train the model, continuing the saved model if modelFile is also
+// set,
+// (1.2) save the model if saveFile is set.
+// (1.3) If modelFile is set, load the saved model.
+// (2) If importanceFile is set, write the feature importance.
+// (2.1) If permImportanceFile is set and the model is trained, write the
+// permutation importance.
+// (2.2) If dotFile is set, write the tree in DOT format.
+// (3) If testCfg parameter is set,
+// (3.1) Test the model using data from testCfg.
 //
 func main() {
 	defer un(trace())
@@ -181,8 +427,15 @@ func main() {
 	if trainCfg != "" {
 		// (1.1)
 		train()
-	} else {
+
 		// (1.2)
+		if saveFile != "" {
+			save()
+		}
+	} else if modelFile != "" {
+		// (1.3)
+		load()
+	} else {
 		if len(flag.Args()) <= 0 {
 			usage()
 			os.Exit(1)
@@ -190,6 +443,21 @@ func main() {
 	}
 
 	// (2)
+	if importanceFile != "" {
+		writeImportance()
+	}
+
+	// (2.1)
+	if permImportanceFile != "" && trainCfg != "" {
+		writePermImportance()
+	}
+
+	// (2.2)
+	if dotFile != "" {
+		writeDOT()
+	}
+
+	// (3)
 	if testCfg != "" {
 		test()
 	}
@@ -0,0 +1,492 @@
+// Copyright 2016 Mhd Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+/*
+Package entropy contain function to compute information gain and gain ratio.
+
+Information gain measure the reduction of entropy of target attribute after
+samples is splitted by attribute value. Gain ratio, which is used by C4.5,
+normalize the information gain by the entropy of the split itself (split
+information), to reduce the bias toward split with many values.
+
+	Quinlan, J. Ross. C4.5: programs for machine learning. Elsevier, 1993.
+*/
+package entropy
+
+import (
+	"fmt"
+	"github.com/shuLhan/numerus"
+	"github.com/shuLhan/tekstus"
+	"math"
+	"os"
+	"strconv"
+)
+
+var (
+	// DEBUG debug level, set from environment.
+	DEBUG = 0
+)
+
+/*
+Entropy contain slice of sorted index, slice of partition values, and the
+information gain and gain ratio for each partition.
+*/
+type Entropy struct {
+	// Skip if its true, the gain value would not be searched on this
+	// instance.
+	Skip bool
+	// IsContinu define whether the gain came from continuous attribute
+	// or not.
+	IsContinu bool
+	// IsRatio if its true, the maximum gain is selected using gain ratio
+	// instead of information gain.
+	IsRatio bool
+	// Value of entropy for all target values.
+	Value float64
+	// MaxPartGain contain the index of partition which have the maximum
+	// gain.
+	MaxPartGain int
+	// MaxGainValue contain maximum information gain, or the gain ratio
+	// of partition with maximum information gain if IsRatio is true.
+	MaxGainValue float64
+	// MaxInfoGain contain the maximum information gain.
+	MaxInfoGain float64
+	// SortedIndex of attribute, sorted by values of attribute. This will
+	// be used to reference the unsorted target attribute.
+	SortedIndex []int
+	// ContinuPart contain list of partition value for continuous attribute.
+	ContinuPart []float64
+	// DiscretePart contain the possible combination of discrete values.
+	DiscretePart tekstus.TableStrings
+	// Gain contain information gain for each partition.
+	Gain []float64
+	// Ratio contain gain ratio for each partition.
+	Ratio []float64
+	// MinLeaf define the minimum number of samples in each partition.
+	// Partition which has less samples than this value will not be
+	// selected as the partition with maximum gain.
+	MinLeaf int
+}
+
+func init() {
+	v := os.Getenv("ENTROPY_DEBUG")
+	if v == "" {
+		DEBUG = 0
+	} else {
+		DEBUG, _ = strconv.Atoi(v)
+	}
+}
+
+/*
+Compute return the entropy of target T which contain classes C,
+
+	-1 * sum (p(c) * log2(p(c)))
+
+where p(c) is probability of class c in T.
+*/
+func Compute(T []string, C []string) float64 {
+	counts, n := countClass(T, classIndex(C))
+
+	return computeCounts(counts, n)
+}
+
+//
+// classIndex return map of class value to their index in C.
+//
+func classIndex(C []string) map[string]int {
+	idx := make(map[string]int, len(C))
+	for x, c := range C {
+		idx[c] = x
+	}
+	return idx
+}
+
+//
+// countClass return number of each class in T and total samples.
+//
+func countClass(T []string, cidx map[string]int) (counts []int, n int) {
+	counts = make([]int, len(cidx))
+	for _, t := range T {
+		x, ok := cidx[t]
+		if !ok {
+			continue
+		}
+		counts[x]++
+		n++
+	}
+	return
+}
+
+//
+// computeCounts return the entropy from number of samples in each class.
+//
+func computeCounts(counts []int, n int) (v float64) {
+	if n <= 0 {
+		return 0
+	}
+
+	total := float64(n)
+
+	for _, c := range counts {
+		if c <= 0 {
+			continue
+		}
+		p := float64(c) / total
+		v -= p * math.Log2(p)
+	}
+	return
+}
+
+/*
+ComputeContinu Given a continuous attribute A and the target attribute T which
+contain N classes in C, compute the information gain and gain ratio for each
+partition of A.
+
+The result of partition value, gain, and ratio is saved in ContinuPart, Gain,
+and Ratio.
+*/
+func (entropy *Entropy) ComputeContinu(A *[]float64, T *[]string,
+	C *[]string,
+) {
+	entropy.IsContinu = true
+	entropy.ContinuPart = nil
+	entropy.Gain = nil
+	entropy.Ratio = nil
+
+	// make a copy of attribute, and sort the target by attribute values.
+	A2 := make([]float64, len(*A))
+	copy(A2, *A)
+
+	entropy.SortedIndex = numerus.Floats64IndirectSort(A2, true)
+
+	T2 := make([]string, len(*T))
+	for x, idx := range entropy.SortedIndex {
+		T2[x] = (*T)[idx]
+	}
+
+	cidx := classIndex(*C)
+	total, n := countClass(T2, cidx)
+
+	entropy.Value = computeCounts(total, n)
+
+	left := make([]int, len(total))
+	right := make([]int, len(total))
+	nleft := 0
+
+	for x := 0; x < len(A2)-1; x++ {
+		if c, ok := cidx[T2[x]]; ok {
+			left[c]++
+			nleft++
+		}
+
+		if A2[x] == A2[x+1] {
+			continue
+		}
+
+		for c := range total {
+			right[c] = total[c] - left[c]
+		}
+
+		nright := n - nleft
+
+		gain, ratio := entropy.computePartGain(
+			[][]int{left, right}, []int{nleft, nright}, n)
+
+		if nleft < entropy.MinLeaf || nright < entropy.MinLeaf {
+			gain, ratio = 0, 0
+		}
+
+		part := (A2[x] + A2[x+1]) / 2
+
+		entropy.ContinuPart = append(entropy.ContinuPart, part)
+		entropy.Gain = append(entropy.Gain, gain)
+		entropy.Ratio = append(entropy.Ratio, ratio)
+
+		if DEBUG >= 3 {
+			fmt.Printf("[entropy] Gain(%v) = %f, ratio = %f\n",
+				part, gain, ratio)
+		}
+	}
+
+	entropy.findMaxGain()
+}
+
+/*
+ComputeContinuAt Given a continuous attribute A and the target attribute T
+which contain N classes in C, compute the information gain and gain ratio only
+on one partition value `part`, where the left partition is samples with value
+less than `part`.
+
+Unlike ComputeContinu, the attribute is not sorted, so SortedIndex is empty.
+This is used to split the samples using random partition value, as in
+extremely randomized trees.
+*/
+func (entropy *Entropy) ComputeContinuAt(A *[]float64, T *[]string,
+	C *[]string, part float64,
+) {
+	entropy.IsContinu = true
+	entropy.SortedIndex = nil
+	entropy.ContinuPart = []float64{part}
+	entropy.Gain = make([]float64, 1)
+	entropy.Ratio = make([]float64, 1)
+
+	cidx := classIndex(*C)
+	total, n := countClass(*T, cidx)
+
+	entropy.Value = computeCounts(total, n)
+
+	left := make([]int, len(total))
+	right := make([]int, len(total))
+	nleft := 0
+
+	for x, attrVal := range *A {
+		if attrVal >= part {
+			continue
+		}
+		if c, ok := cidx[(*T)[x]]; ok {
+			left[c]++
+			nleft++
+		}
+	}
+
+	for c := range total {
+		right[c] = total[c] - left[c]
+	}
+
+	nright := n - nleft
+
+	entropy.Gain[0], entropy.Ratio[0] = entropy.computePartGain(
+		[][]int{left, right}, []int{nleft, nright}, n)
+
+	if nleft < entropy.MinLeaf || nright < entropy.MinLeaf {
+		entropy.Gain[0], entropy.Ratio[0] = 0, 0
+	}
+
+	if DEBUG >= 3 {
+		fmt.Printf("[entropy] Gain(%v) = %f, ratio = %f\n", part,
+			entropy.Gain[0], entropy.Ratio[0])
+	}
+
+	entropy.findMaxGain()
+}
+
+/*
+ComputeDiscrete Given an attribute A with discrete value 'discval', and the
+target attribute T which contain N classes in C, compute the information gain
+and gain ratio for each partition of discrete values.
+*/
+func (entropy *Entropy) ComputeDiscrete(A *[]string, discval *[]string,
+	T *[]string, C *[]string,
+) {
+	entropy.IsContinu = false
+	entropy.SortedIndex = nil
+	entropy.DiscretePart = nil
+	entropy.Gain = nil
+	entropy.Ratio = nil
+	entropy.MaxGainValue = 0
+	entropy.MaxInfoGain = 0
+	entropy.MaxPartGain = 0
+
+	if len(*discval) <= 0 {
+		return
+	}
+
+	entropy.DiscretePart = tekstus.Strings(*discval).Partitioning(2)
+	entropy.Gain = make([]float64, len(entropy.DiscretePart))
+	entropy.Ratio = make([]float64, len(entropy.DiscretePart))
+
+	cidx := classIndex(*C)
+	_, n := countClass(*T, cidx)
+
+	entropy.Value = Compute(*T, *C)
+
+	for i, subPart := range entropy.DiscretePart {
+		counts := make([][]int, len(subPart))
+		sizes := make([]int, len(subPart))
+
+		for p, part := range subPart {
+			counts[p] = make([]int, len(cidx))
+
+			for t, a := range *A {
+				for _, el := range part {
+					if a != el {
+						continue
+					}
+					if c, ok := cidx[(*T)[t]]; ok {
+						counts[p][c]++
+						sizes[p]++
+					}
+					break
+				}
+			}
+		}
+
+		entropy.Gain[i], entropy.Ratio[i] = entropy.computePartGain(
+			counts, sizes, n)
+
+		for _, size := range sizes {
+			if size < entropy.MinLeaf {
+				entropy.Gain[i], entropy.Ratio[i] = 0, 0
+				break
+			}
+		}
+
+		if DEBUG >= 3 {
+			fmt.Printf("[entropy] Gain(%v) = %f, ratio = %f\n",
+				subPart, entropy.Gain[i], entropy.Ratio[i])
+		}
+	}
+
+	entropy.findMaxGain()
+}
+
+/*
+computePartGain compute the information gain and gain ratio of splitting `n`
+samples into partitions, where `counts` contain number of each class and
+`sizes` contain number of samples in each partition.
+
+The formula is,
+
+	Gain(part,S) = Entropy(S) - sum (|S_i|/|S| * Entropy(S_i))
+	SplitInfo(part,S) = - sum (|S_i|/|S| * log2(|S_i|/|S|))
+	GainRatio(part,S) = Gain(part,S) / SplitInfo(part,S)
+*/
+func (entropy *Entropy) computePartGain(counts [][]int, sizes []int, n int) (
+	gain, ratio float64,
+) {
+	if n <= 0 {
+		return 0, 0
+	}
+
+	total := float64(n)
+	sumEntropy := 0.0
+
+	for p := range counts {
+		sumEntropy += float64(sizes[p]) / total *
+			computeCounts(counts[p], sizes[p])
+	}
+
+	gain = entropy.Value - sumEntropy
+
+	// Avoid small negative value caused by rounding error.
+	if gain < 0 {
+		gain = 0
+	}
+
+	splitInfo := computeCounts(sizes, n)
+	if splitInfo > 0 {
+		ratio = gain / splitInfo
+	}
+
+	return gain, ratio
+}
+
+/*
+findMaxGain set the partition with the maximum information gain.
+
+If IsRatio is true, MaxGainValue is set to the gain ratio of the selected
+partition. As in C4.5, the attribute is then selected by gain.FindMaxGain
+using the maximum gain ratio, only from the attributes which information gain
+is at least the average gain of all attributes.
+*/
+func (entropy *Entropy) findMaxGain() {
+	entropy.MaxInfoGain = 0
+	entropy.MaxPartGain = 0
+
+	for x, gain := range entropy.Gain {
+		if entropy.MaxInfoGain < gain {
+			entropy.MaxInfoGain = gain
+			entropy.MaxPartGain = x
+		}
+	}
+
+	entropy.MaxGainValue = entropy.MaxInfoGain
+
+	if entropy.IsRatio && entropy.MaxInfoGain > 0 {
+		entropy.MaxGainValue = entropy.Ratio[entropy.MaxPartGain]
+	}
+}
+
+/*
+IsSkipped return true if the gain value would not be searched on this
+instance.
+*/
+func (entropy *Entropy) IsSkipped() bool {
+	return entropy.Skip
+}
+
+/*
+SetSkip set the skip flag.
+*/
+func (entropy *Entropy) SetSkip(skip bool) {
+	entropy.Skip = skip
+}
+
+/*
+IsContinuous return true if gain is computed on continuous attribute.
+*/
+func (entropy *Entropy) IsContinuous() bool {
+	return entropy.IsContinu
+}
+
+/*
+GetSortedIndex return the index of attribute values after sorted.
+*/
+func (entropy *Entropy) GetSortedIndex() []int {
+	return entropy.SortedIndex
+}
+
+/*
+GetMaxPartGainValue return the partition that have the maximum gain.
+*/
+func (entropy *Entropy) GetMaxPartGainValue() interface{} {
+	if entropy.IsContinu {
+		return entropy.ContinuPart[entropy.MaxPartGain]
+	}
+
+	return entropy.DiscretePart[entropy.MaxPartGain]
+}
+
+/*
+GetMaxGainValue return the maximum information gain, or gain ratio if IsRatio
+is true.
+*/
+func (entropy *Entropy) GetMaxGainValue() float64 {
+	return entropy.MaxGainValue
+}
+
+/*
+IsGainRatio return true if the attribute should be selected using gain ratio.
+*/
+func (entropy *Entropy) IsGainRatio() bool {
+	return entropy.IsRatio
+}
+
+/*
+GetMaxInfoGainValue return the information gain of the selected partition.
+*/
+func (entropy *Entropy) GetMaxInfoGainValue() float64 {
+	return entropy.MaxInfoGain
+}
+
+/*
+String yes, it will print it JSON like format.
+*/
+func (entropy Entropy) String() (s string) {
+	s = fmt.Sprint("{\n",
+		"  Skip          :", entropy.Skip, "\n",
+		"  IsContinu     :", entropy.IsContinu, "\n",
+		"  IsRatio       :", entropy.IsRatio, "\n",
+		"  Value         :", entropy.Value, "\n",
+		"  Gain          :", entropy.Gain, "\n",
+		"  Ratio         :", entropy.Ratio, "\n",
+		"  MaxPartGain   :", entropy.MaxPartGain, "\n",
+		"  MaxGainValue  :", entropy.MaxGainValue, "\n",
+		"  MaxInfoGain   :", entropy.MaxInfoGain, "\n",
+		"  SortedIndex   :", entropy.SortedIndex, "\n",
+		"  ContinuPart   :", entropy.ContinuPart, "\n",
+		"  DiscretePart  :", entropy.DiscretePart, "\n",
+		"}")
+	return
+}
@@ -0,0 +1,138 @@
+// Copyright 2016 Mhd Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package entropy_test
+
+import (
+	"testing"
+
+	"github.com/shuLhan/go-mining/gain"
+	"github.com/shuLhan/go-mining/gain/entropy"
+)
+
+var classes = []string{"P", "N"}
+
+func TestCompute(t *testing.T) {
+	got := entropy.Compute([]string{"P", "P", "N", "N"}, classes)
+	if got != 1 {
+		t.Fatalf("Expecting entropy 1, got %f", got)
+	}
+
+	got = entropy.Compute([]string{"P", "P"}, classes)
+	if got != 0 {
+		t.Fatalf("Expecting entropy 0, got %f", got)
+	}
+}
+
+func TestComputeContinu(t *testing.T) {
+	A := []float64{4, 1, 3, 2}
+	T := []string{"N", "P", "N", "P"}
+
+	ent := entropy.Entropy{}
+	ent.ComputeContinu(&A, &T, &classes)
+
+	if ent.GetMaxGainValue() != 1 {
+		t.Fatalf("Expecting max gain 1, got %f", ent.GetMaxGainValue())
+	}
+
+	split := ent.GetMaxPartGainValue().(float64)
+	if split != 2.5 {
+		t.Fatalf("Expecting split value 2.5, got %f", split)
+	}
+
+	// Make sure the input is not modified.
+	if A[0] != 4 || T[0] != "N" {
+		t.Fatalf("Input has been modified: %v %v", A, T)
+	}
+}
+
+func TestComputeContinuAt(t *testing.T) {
+	A := []float64{4, 1, 3, 2}
+	T := []string{"N", "P", "N", "P"}
+
+	ent := entropy.Entropy{}
+	ent.ComputeContinuAt(&A, &T, &classes, 2.5)
+
+	if ent.GetMaxGainValue() != 1 {
+		t.Fatalf("Expecting max gain 1, got %f", ent.GetMaxGainValue())
+	}
+	if len(ent.GetSortedIndex()) != 0 {
+		t.Fatalf("Expecting empty sorted index, got %v",
+			ent.GetSortedIndex())
+	}
+
+	// Partition that leave one sample on the left is not selected.
+	ent = entropy.Entropy{MinLeaf: 2}
+	ent.ComputeContinuAt(&A, &T, &classes, 1.5)
+
+	if ent.GetMaxGainValue() != 0 {
+		t.Fatalf("Expecting max gain 0, got %f", ent.GetMaxGainValue())
+	}
+}
+
+func TestComputeContinuRatio(t *testing.T) {
+	A := []float64{1, 2, 3, 4, 5, 6}
+	T := []string{"P", "N", "N", "N", "N", "N"}
+
+	ent := entropy.Entropy{IsRatio: true}
+	ent.ComputeContinu(&A, &T, &classes)
+
+	// Both information gain and gain ratio select the first partition,
+	// which separate all P from N.
+	split := ent.GetMaxPartGainValue().(float64)
+	if split != 1.5 {
+		t.Fatalf("Expecting split value 1.5, got %f", split)
+	}
+
+	// The gain ratio is 1, because the split information is equal to
+	// the entropy of target.
+	if ent.GetMaxGainValue() != 1 {
+		t.Fatalf("Expecting gain ratio 1, got %f",
+			ent.GetMaxGainValue())
+	}
+}
+
+func TestComputeDiscrete(t *testing.T) {
+	A := []string{"a", "b", "a", "b"}
+	discval := []string{"a", "b"}
+	T := []string{"P", "N", "P", "N"}
+
+	ent := entropy.Entropy{}
+	ent.ComputeDiscrete(&A, &discval, &T, &classes)
+
+	if ent.GetMaxGainValue() != 1 {
+		t.Fatalf("Expecting max gain 1, got %f", ent.GetMaxGainValue())
+	}
+}
+
+func TestFindMaxGainRatio(t *testing.T) {
+	gains := []gain.Interface{
+		// Class attribute.
+		&entropy.Entropy{Skip: true, IsRatio: true, MaxGainValue: 1,
+			MaxInfoGain: 1},
+		&entropy.Entropy{IsRatio: true, MaxGainValue: 0.3,
+			MaxInfoGain: 0.9},
+		// Highest ratio, but the gain is below the average.
+		&entropy.Entropy{IsRatio: true, MaxGainValue: 0.9,
+			MaxInfoGain: 0.1},
+		&entropy.Entropy{IsRatio: true, MaxGainValue: 0.5,
+			MaxInfoGain: 0.5},
+	}
+
+	got := gain.FindMaxGain(gains)
+	if got != 3 {
+		t.Fatalf("Expecting attribute 3, got %d", got)
+	}
+
+	// Without gain ratio, the attribute is selected by information
+	// gain, which is in MaxGainValue.
+	for _, g := range gains {
+		g.(*entropy.Entropy).IsRatio = false
+	}
+
+	got = gain.FindMaxGain(gains)
+	if got != 2 {
+		t.Fatalf("Expecting attribute 2, got %d", got)
+	}
+}
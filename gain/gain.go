@@ -0,0 +1,135 @@
+// Copyright 2016 Mhd Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+/*
+Package gain define the common interface for computing the gain of splitting
+samples by attribute with discrete class, e.g. Gini gain or information gain.
+
+The implementation of each gain is in their own sub-package.
+*/
+package gain
+
+/*
+Interface define the methods that must be implemented by split criterion to be
+used by decision tree.
+*/
+type Interface interface {
+	// IsSkipped return true if the gain value would not be searched on
+	// this instance.
+	IsSkipped() bool
+	// SetSkip set the skip flag.
+	SetSkip(skip bool)
+	// IsContinuous return true if gain is computed on continuous
+	// attribute.
+	IsContinuous() bool
+	// GetSortedIndex return the index of attribute values sorted in
+	// ascending order. Only valid on continuous attribute.
+	GetSortedIndex() []int
+	// ComputeContinu compute the gain for each partition of continuous
+	// attribute A with target attribute T which contain classes C.
+	ComputeContinu(A *[]float64, T *[]string, C *[]string)
+	// ComputeDiscrete compute the gain for each partition of discrete
+	// attribute A, with value space `discval`, and target attribute T
+	// which contain classes C.
+	ComputeDiscrete(A *[]string, discval *[]string, T *[]string,
+		C *[]string)
+	// GetMaxPartGainValue return the partition that have the maximum
+	// gain.
+	GetMaxPartGainValue() interface{}
+	// GetMaxGainValue return the maximum gain value.
+	GetMaxGainValue() float64
+	// GetMaxInfoGainValue return the decrease of impurity of the
+	// partition that is returned by GetMaxPartGainValue. Its equal to
+	// GetMaxGainValue, except when the gain is a ratio.
+	GetMaxInfoGainValue() float64
+}
+
+/*
+RatioInterface define the split criterion that can select the attribute using
+gain ratio, as in C4.5.
+*/
+type RatioInterface interface {
+	Interface
+	// IsGainRatio return true if the attribute should be selected using
+	// gain ratio, which is returned by GetMaxGainValue.
+	IsGainRatio() bool
+}
+
+/*
+FindMaxGain find the attribute and value that have the maximum gain.
+The returned value is index of attribute.
+Gain that is nil or flagged with skip will be ignored.
+
+If the gains use gain ratio, the attribute is selected using FindMaxGainRatio.
+*/
+func FindMaxGain(gains []Interface) (MaxGainIdx int) {
+	var maxGainValue = 0.0
+
+	for i, g := range gains {
+		if g == nil || g.IsSkipped() {
+			continue
+		}
+		if r, ok := g.(RatioInterface); ok && r.IsGainRatio() {
+			return FindMaxGainRatio(gains)
+		}
+		gainValue := g.GetMaxGainValue()
+		if gainValue > maxGainValue {
+			maxGainValue = gainValue
+			MaxGainIdx = i
+		}
+	}
+
+	return
+}
+
+/*
+FindMaxGainRatio find the attribute that have the maximum gain ratio, only
+from the attributes which information gain is at least the average
+information gain of all attributes, as in C4.5.
+The returned value is index of attribute.
+Gain that is nil, flagged with skip, or does not implement RatioInterface
+will be ignored.
+
+	Quinlan, J. Ross. C4.5: programs for machine learning. Elsevier,
+	1993.
+*/
+func FindMaxGainRatio(gains []Interface) (MaxGainIdx int) {
+	var ratios []RatioInterface
+	var idx []int
+
+	avg := 0.0
+	for i, g := range gains {
+		if g == nil || g.IsSkipped() {
+			continue
+		}
+		r, ok := g.(RatioInterface)
+		if !ok {
+			continue
+		}
+		ratios = append(ratios, r)
+		idx = append(idx, i)
+		avg += r.GetMaxInfoGainValue()
+	}
+
+	if len(ratios) == 0 {
+		return 0
+	}
+
+	avg /= float64(len(ratios))
+
+	var maxRatio = 0.0
+
+	for x, r := range ratios {
+		if r.GetMaxInfoGainValue() < avg {
+			continue
+		}
+		ratio := r.GetMaxGainValue()
+		if ratio > maxRatio {
+			maxRatio = ratio
+			MaxGainIdx = idx[x]
+		}
+	}
+
+	return
+}
@@ -57,6 +57,10 @@ type Gini struct {
 	Index []float64
 	// Gain contain information gain for each partition.
 	Gain []float64
+	// MinLeaf define the minimum number of samples in each partition.
+	// Partition which has less samples than this value will not be
+	// selected as the partition with maximum gain.
+	MinLeaf int
 }
 
 func init() {
@@ -116,6 +120,7 @@ func (gini *Gini) computeDiscreteGain(A *[]string, T *[]string, C *[]string) {
 		}
 
 		sumGI := 0.0
+		isTooSmall := false
 		for _, part := range subPart {
 			ndisc := 0.0
 			var subT []string
@@ -133,6 +138,10 @@ func (gini *Gini) computeDiscreteGain(A *[]string, T *[]string, C *[]string) {
 				}
 			}
 
+			if int(ndisc) < gini.MinLeaf {
+				isTooSmall = true
+			}
+
 			// compute gini index for subtarget
 			giniIndex := gini.compute(&subT, C)
 
@@ -162,6 +171,10 @@ func (gini *Gini) computeDiscreteGain(A *[]string, T *[]string, C *[]string) {
 				gini.Gain[i])
 		}
 
+		if isTooSmall {
+			continue
+		}
+
 		if gini.MinIndexValue > gini.Index[i] && gini.Index[i] != 0 {
 			gini.MinIndexValue = gini.Index[i]
 			gini.MinIndexPart = i
@@ -364,6 +377,10 @@ func (gini *Gini) computeContinuGain(A *[]float64, T *[]string, C *[]string) {
 				pright, gright, gini.Gain[p])
 		}
 
+		if nleft < gini.MinLeaf || nright < gini.MinLeaf {
+			continue
+		}
+
 		if gini.MinIndexValue > gini.Index[p] && gini.Index[p] != 0 {
 			gini.MinIndexValue = gini.Index[p]
 			gini.MinIndexPart = p
@@ -376,6 +393,91 @@ func (gini *Gini) computeContinuGain(A *[]float64, T *[]string, C *[]string) {
 	}
 }
 
+/*
+ComputeContinuAt Given an attribute A and the target attribute T which contain
+N classes in C, compute the Gini index and Gini gain only on one partition
+value `part`, where the left partition is samples with value less than
+`part`.
+
+Unlike ComputeContinu, the attribute is not sorted, so SortedIndex is empty.
+This is used to split the samples using random partition value, as in
+extremely randomized trees.
+*/
+func (gini *Gini) ComputeContinuAt(A *[]float64, T *[]string, C *[]string,
+	part float64,
+) {
+	var tleft, tright []string
+
+	gini.IsContinu = true
+	gini.SortedIndex = nil
+	gini.ContinuPart = []float64{part}
+	gini.Index = make([]float64, 1)
+	gini.Gain = make([]float64, 1)
+	gini.MaxPartGain = 0
+	gini.MinIndexPart = 0
+
+	for x, attrVal := range *A {
+		if attrVal < part {
+			tleft = append(tleft, (*T)[x])
+		} else {
+			tright = append(tright, (*T)[x])
+		}
+	}
+
+	nsample := float64(len(*A))
+	if nsample == 0 {
+		return
+	}
+
+	pleft := float64(len(tleft)) / nsample
+	pright := float64(len(tright)) / nsample
+
+	gini.Value = gini.compute(T, C)
+	gini.Index[0] = (pleft * gini.compute(&tleft, C)) +
+		(pright * gini.compute(&tright, C))
+	gini.Gain[0] = gini.Value - gini.Index[0]
+
+	gini.MinIndexValue = gini.Index[0]
+	gini.MaxGainValue = gini.Gain[0]
+
+	if len(tleft) < gini.MinLeaf || len(tright) < gini.MinLeaf {
+		gini.MaxGainValue = 0
+	}
+
+	if DEBUG >= 3 {
+		fmt.Printf("[gini] GiniGain(%v) = %f\n", part, gini.Gain[0])
+	}
+}
+
+/*
+IsSkipped return true if the gain value would not be searched on this
+instance.
+*/
+func (gini *Gini) IsSkipped() bool {
+	return gini.Skip
+}
+
+/*
+SetSkip set the skip flag.
+*/
+func (gini *Gini) SetSkip(skip bool) {
+	gini.Skip = skip
+}
+
+/*
+IsContinuous return true if Gini gain is computed on continuous attribute.
+*/
+func (gini *Gini) IsContinuous() bool {
+	return gini.IsContinu
+}
+
+/*
+GetSortedIndex return the index of attribute values after sorted.
+*/
+func (gini *Gini) GetSortedIndex() []int {
+	return gini.SortedIndex
+}
+
 /*
 GetMaxPartGainValue return the partition that have the maximum Gini gain.
 */
@@ -395,6 +497,14 @@ func (gini *Gini) GetMaxGainValue() float64 {
 	return gini.MaxGainValue
 }
 
+/*
+GetMaxInfoGainValue return the maximum Gini gain. Its the same as
+GetMaxGainValue, because Gini gain is not a ratio.
+*/
+func (gini *Gini) GetMaxInfoGainValue() float64 {
+	return gini.MaxGainValue
+}
+
 /*
 GetMinIndexPartValue return the partition that have the minimum Gini index.
 */
@@ -59,3 +59,53 @@ func TestComputeDiscrete(t *testing.T) {
 		fmt.Println(gini)
 	}
 }
+
+func TestComputeContinuAt(t *testing.T) {
+	target := make([]string, len(targetValues))
+	copy(target, targetValues)
+
+	exp := gini.Gini{}
+	exp.ComputeContinu(&data[0], &target, &classes)
+
+	// The partition with maximum gain should give the same gain when
+	// computed alone.
+	part := exp.GetMaxPartGainValue().(float64)
+
+	got := gini.Gini{}
+	got.ComputeContinuAt(&data[0], &target, &classes, part)
+
+	if got.GetMaxGainValue() != exp.GetMaxGainValue() {
+		t.Fatalf("Expecting gain %f, got %f", exp.GetMaxGainValue(),
+			got.GetMaxGainValue())
+	}
+	if got.GetMaxPartGainValue().(float64) != part {
+		t.Fatalf("Expecting partition %f, got %v", part,
+			got.GetMaxPartGainValue())
+	}
+	if len(got.GetSortedIndex()) != 0 {
+		t.Fatalf("Expecting empty sorted index, got %v",
+			got.GetSortedIndex())
+	}
+}
+
+func TestComputeContinuMinLeaf(t *testing.T) {
+	A := []float64{1, 2, 3, 4, 5, 6}
+	T := []string{"a", "b", "b", "b", "b", "b"}
+	C := []string{"a", "b"}
+
+	got := gini.Gini{}
+	got.ComputeContinu(&A, &T, &C)
+
+	if part := got.GetMaxPartGainValue().(float64); part != 1.5 {
+		t.Fatalf("Expecting partition 1.5, got %f", part)
+	}
+
+	// The best partition leave one sample on the left, so it should not
+	// be selected.
+	got = gini.Gini{MinLeaf: 2}
+	got.ComputeContinu(&A, &T, &C)
+
+	if part := got.GetMaxPartGainValue().(float64); part != 2.5 {
+		t.Fatalf("Expecting partition 2.5, got %f", part)
+	}
+}
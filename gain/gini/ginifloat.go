@@ -153,6 +153,10 @@ func (gini *Gini) computeContinuGainFloat(A, T, C *[]float64) {
 				probRight, gainRight, gini.Gain[p])
 		}
 
+		if int(nleft) < gini.MinLeaf || int(nright) < gini.MinLeaf {
+			continue
+		}
+
 		if gini.MinIndexValue > gini.Index[p] && gini.Index[p] != 0 {
 			gini.MinIndexValue = gini.Index[p]
 			gini.MinIndexPart = p
@@ -0,0 +1,375 @@
+// Copyright 2016 Mhd Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+/*
+Package variance contain function to compute the reduction of variance.
+
+Variance reduction is the splitting criterion used in regression tree, where
+the target attribute is numeric. Reducing the variance of target in each
+partition is equal to reducing the mean squared error of predicting each
+partition with their mean.
+*/
+package variance
+
+import (
+	"fmt"
+	"github.com/shuLhan/numerus"
+	"github.com/shuLhan/tekstus"
+	"os"
+	"strconv"
+)
+
+var (
+	// DEBUG debug level, set from environment.
+	DEBUG = 0
+)
+
+/*
+Variance contain slice of sorted index, slice of partition values, and the
+reduction of variance for each partition.
+*/
+type Variance struct {
+	// Skip if its true, the gain value would not be searched on this
+	// instance.
+	Skip bool
+	// IsContinu define whether the variance came from continuous
+	// attribute or not.
+	IsContinu bool
+	// Value of variance for all target values.
+	Value float64
+	// MaxPartGain contain the index of partition which have the maximum
+	// gain.
+	MaxPartGain int
+	// MaxGainValue contain maximum gain of index.
+	MaxGainValue float64
+	// SortedIndex of attribute, sorted by values of attribute. This will
+	// be used to reference the unsorted target attribute.
+	SortedIndex []int
+	// ContinuPart contain list of partition value for continuous attribute.
+	ContinuPart []float64
+	// DiscretePart contain the possible combination of discrete values.
+	DiscretePart tekstus.TableStrings
+	// Gain contain the reduction of variance for each partition.
+	Gain []float64
+	// MinLeaf define the minimum number of samples in each partition.
+	// Partition which has less samples than this value will not be
+	// selected as the partition with maximum gain.
+	MinLeaf int
+}
+
+func init() {
+	v := os.Getenv("VARIANCE_DEBUG")
+	if v == "" {
+		DEBUG = 0
+	} else {
+		DEBUG, _ = strconv.Atoi(v)
+	}
+}
+
+/*
+Compute return the population variance of T,
+
+	sum((T[i] - mean(T))^2) / len(T)
+*/
+func Compute(T []float64) float64 {
+	n := float64(len(T))
+	if n == 0 {
+		return 0
+	}
+
+	var sum, sum2 float64
+	for _, t := range T {
+		sum += t
+		sum2 += t * t
+	}
+
+	mean := sum / n
+	v := (sum2 / n) - (mean * mean)
+
+	// Avoid negative value caused by rounding error.
+	if v < 0 {
+		return 0
+	}
+	return v
+}
+
+/*
+ComputeContinu Given a continuous attribute A and the numeric target
+attribute T, compute the reduction of variance for each partition of A.
+
+The result of partitions value and their gain is saved in ContinuPart and
+Gain.
+*/
+func (variance *Variance) ComputeContinu(A *[]float64, T *[]float64) {
+	variance.IsContinu = true
+	variance.MaxGainValue = 0
+	variance.MaxPartGain = 0
+
+	// make a copy of attribute and target.
+	A2 := make([]float64, len(*A))
+	copy(A2, *A)
+
+	T2 := make([]float64, len(*T))
+	copy(T2, *T)
+
+	variance.SortedIndex = numerus.Floats64IndirectSort(A2, true)
+
+	numerus.Floats64SortByIndex(&T2, variance.SortedIndex)
+
+	variance.Value = Compute(T2)
+
+	variance.computeContinuGain(A2, T2)
+}
+
+/*
+computeContinuGain compute the gain for each partition between two distinct
+values in sorted attribute A.
+
+The gain formula is,
+
+	Gain(part,S) = Var(S) - ((count(left)/S * Var(left))
+				+ (count(right)/S * Var(right)))
+*/
+func (variance *Variance) computeContinuGain(A, T []float64) {
+	n := len(A)
+
+	variance.ContinuPart = nil
+	variance.Gain = nil
+
+	if n < 2 {
+		return
+	}
+
+	// Cumulative sum of target and squared target, so the variance of
+	// each partition can be computed in constant time.
+	sums := make([]float64, n+1)
+	sums2 := make([]float64, n+1)
+	for x, t := range T {
+		sums[x+1] = sums[x] + t
+		sums2[x+1] = sums2[x] + t*t
+	}
+
+	partVar := func(sum, sum2, cnt float64) float64 {
+		mean := sum / cnt
+		v := (sum2 / cnt) - (mean * mean)
+		if v < 0 {
+			return 0
+		}
+		return v
+	}
+
+	total := float64(n)
+
+	for x := 0; x < n-1; x++ {
+		if A[x] == A[x+1] {
+			continue
+		}
+
+		nleft := float64(x + 1)
+		nright := total - nleft
+
+		varLeft := partVar(sums[x+1], sums2[x+1], nleft)
+		varRight := partVar(sums[n]-sums[x+1], sums2[n]-sums2[x+1],
+			nright)
+
+		gain := variance.Value - ((nleft/total)*varLeft +
+			(nright/total)*varRight)
+
+		variance.ContinuPart = append(variance.ContinuPart,
+			(A[x]+A[x+1])/2)
+		variance.Gain = append(variance.Gain, gain)
+
+		if DEBUG >= 3 {
+			fmt.Printf("[variance] Gain(%v) = %f\n",
+				(A[x]+A[x+1])/2, gain)
+		}
+
+		if int(nleft) < variance.MinLeaf ||
+			int(nright) < variance.MinLeaf {
+			continue
+		}
+
+		if variance.MaxGainValue < gain {
+			variance.MaxGainValue = gain
+			variance.MaxPartGain = len(variance.Gain) - 1
+		}
+	}
+}
+
+/*
+ComputeContinuAt Given a continuous attribute A and the numeric target
+attribute T, compute the reduction of variance only on one partition value
+`part`, where the left partition is samples with value less than `part`.
+
+Unlike ComputeContinu, the attribute is not sorted, so SortedIndex is empty.
+This is used to split the samples using random partition value, as in
+extremely randomized trees.
+*/
+func (variance *Variance) ComputeContinuAt(A *[]float64, T *[]float64,
+	part float64,
+) {
+	var tleft, tright []float64
+
+	variance.IsContinu = true
+	variance.SortedIndex = nil
+	variance.ContinuPart = []float64{part}
+	variance.Gain = make([]float64, 1)
+	variance.MaxGainValue = 0
+	variance.MaxPartGain = 0
+
+	for x, attrVal := range *A {
+		if attrVal < part {
+			tleft = append(tleft, (*T)[x])
+		} else {
+			tright = append(tright, (*T)[x])
+		}
+	}
+
+	nsample := float64(len(*A))
+	if nsample == 0 {
+		return
+	}
+
+	variance.Value = Compute(*T)
+
+	pleft := float64(len(tleft)) / nsample
+	pright := float64(len(tright)) / nsample
+
+	variance.Gain[0] = variance.Value - (pleft*Compute(tleft) +
+		pright*Compute(tright))
+
+	if DEBUG >= 3 {
+		fmt.Printf("[variance] Gain(%v) = %f\n", part, variance.Gain[0])
+	}
+
+	if len(tleft) < variance.MinLeaf || len(tright) < variance.MinLeaf {
+		return
+	}
+
+	if variance.Gain[0] > 0 {
+		variance.MaxGainValue = variance.Gain[0]
+	}
+}
+
+/*
+ComputeDiscrete Given an attribute A with discrete value 'discval', and the
+numeric target attribute T, compute the reduction of variance for each
+partition of discrete values.
+*/
+func (variance *Variance) ComputeDiscrete(A *[]string, discval *[]string,
+	T *[]float64,
+) {
+	variance.IsContinu = false
+	variance.MaxGainValue = 0
+	variance.MaxPartGain = 0
+	variance.SortedIndex = nil
+
+	if len(*discval) <= 0 {
+		return
+	}
+
+	variance.DiscretePart = tekstus.Strings(*discval).Partitioning(2)
+	variance.Gain = make([]float64, len(variance.DiscretePart))
+	variance.Value = Compute(*T)
+
+	nsample := float64(len(*A))
+
+	for i, subPart := range variance.DiscretePart {
+		sumVar := 0.0
+		isTooSmall := false
+
+		for _, part := range subPart {
+			var subT []float64
+
+			for t, a := range *A {
+				for _, el := range part {
+					if a == el {
+						subT = append(subT, (*T)[t])
+						break
+					}
+				}
+			}
+
+			if len(subT) < variance.MinLeaf {
+				isTooSmall = true
+			}
+
+			p := float64(len(subT)) / nsample
+			sumVar += p * Compute(subT)
+		}
+
+		variance.Gain[i] = variance.Value - sumVar
+
+		if DEBUG >= 3 {
+			fmt.Printf("[variance] Gain(%v) = %f\n", subPart,
+				variance.Gain[i])
+		}
+
+		if isTooSmall {
+			continue
+		}
+
+		if variance.MaxGainValue < variance.Gain[i] {
+			variance.MaxGainValue = variance.Gain[i]
+			variance.MaxPartGain = i
+		}
+	}
+}
+
+/*
+GetMaxPartGainValue return the partition that have the maximum gain.
+*/
+func (variance *Variance) GetMaxPartGainValue() interface{} {
+	if variance.IsContinu {
+		return variance.ContinuPart[variance.MaxPartGain]
+	}
+
+	return variance.DiscretePart[variance.MaxPartGain]
+}
+
+/*
+GetMaxGainValue return the maximum reduction of variance.
+*/
+func (variance *Variance) GetMaxGainValue() float64 {
+	return variance.MaxGainValue
+}
+
+/*
+FindMaxGain find the attribute and value that have the maximum gain.
+The returned value is index of attribute.
+*/
+func FindMaxGain(gains *[]Variance) (MaxGainIdx int) {
+	var maxGainValue = 0.0
+
+	for i := range *gains {
+		if (*gains)[i].Skip {
+			continue
+		}
+		gainValue := (*gains)[i].GetMaxGainValue()
+		if gainValue > maxGainValue {
+			maxGainValue = gainValue
+			MaxGainIdx = i
+		}
+	}
+
+	return
+}
+
+/*
+String yes, it will print it JSON like format.
+*/
+func (variance Variance) String() (s string) {
+	s = fmt.Sprint("{\n",
+		"  Skip          :", variance.Skip, "\n",
+		"  IsContinu     :", variance.IsContinu, "\n",
+		"  Value         :", variance.Value, "\n",
+		"  Gain          :", variance.Gain, "\n",
+		"  MaxPartGain   :", variance.MaxPartGain, "\n",
+		"  MaxGainValue  :", variance.MaxGainValue, "\n",
+		"  SortedIndex   :", variance.SortedIndex, "\n",
+		"  ContinuPart   :", variance.ContinuPart, "\n",
+		"  DiscretePart  :", variance.DiscretePart, "\n",
+		"}")
+	return
+}
@@ -0,0 +1,104 @@
+// Copyright 2016 Mhd Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package variance_test
+
+import (
+	"testing"
+
+	"github.com/shuLhan/go-mining/gain/variance"
+)
+
+func TestCompute(t *testing.T) {
+	got := variance.Compute([]float64{1, 1, 5, 5})
+	if got != 4 {
+		t.Fatalf("Expecting variance 4, got %f", got)
+	}
+
+	got = variance.Compute(nil)
+	if got != 0 {
+		t.Fatalf("Expecting variance 0, got %f", got)
+	}
+}
+
+func TestComputeContinu(t *testing.T) {
+	A := []float64{4, 1, 3, 2}
+	T := []float64{5, 1, 5, 1}
+
+	v := variance.Variance{}
+	v.ComputeContinu(&A, &T)
+
+	if v.GetMaxGainValue() != 4 {
+		t.Fatalf("Expecting max gain 4, got %f", v.GetMaxGainValue())
+	}
+
+	split := v.GetMaxPartGainValue().(float64)
+	if split != 2.5 {
+		t.Fatalf("Expecting split value 2.5, got %f", split)
+	}
+
+	// Make sure the input is not modified.
+	if A[0] != 4 || T[0] != 5 {
+		t.Fatalf("Input has been modified: %v %v", A, T)
+	}
+}
+
+func TestComputeContinuAt(t *testing.T) {
+	A := []float64{4, 1, 3, 2}
+	T := []float64{5, 1, 5, 1}
+
+	v := variance.Variance{}
+	v.ComputeContinuAt(&A, &T, 2.5)
+
+	if v.GetMaxGainValue() != 4 {
+		t.Fatalf("Expecting max gain 4, got %f", v.GetMaxGainValue())
+	}
+	if len(v.SortedIndex) != 0 {
+		t.Fatalf("Expecting empty sorted index, got %v", v.SortedIndex)
+	}
+
+	// Partition that leave one sample on the left is not selected.
+	v = variance.Variance{MinLeaf: 2}
+	v.ComputeContinuAt(&A, &T, 1.5)
+
+	if v.GetMaxGainValue() != 0 {
+		t.Fatalf("Expecting max gain 0, got %f", v.GetMaxGainValue())
+	}
+}
+
+func TestComputeDiscrete(t *testing.T) {
+	A := []string{"a", "b", "a", "b"}
+	discval := []string{"a", "b"}
+	T := []float64{1, 5, 1, 5}
+
+	v := variance.Variance{}
+	v.ComputeDiscrete(&A, &discval, &T)
+
+	if v.GetMaxGainValue() != 4 {
+		t.Fatalf("Expecting max gain 4, got %f", v.GetMaxGainValue())
+	}
+}
+
+func TestComputeContinuMinLeaf(t *testing.T) {
+	A := []float64{1, 2, 3, 4, 5, 6}
+	T := []float64{100, 1, 1, 1, 1, 1}
+
+	v := variance.Variance{}
+	v.ComputeContinu(&A, &T)
+
+	split := v.GetMaxPartGainValue().(float64)
+	if split != 1.5 {
+		t.Fatalf("Expecting split value 1.5, got %f", split)
+	}
+
+	// The best split leave one sample on the left, so it should not be
+	// selected.
+	v = variance.Variance{MinLeaf: 2}
+	v.ComputeContinu(&A, &T)
+
+	split = v.GetMaxPartGainValue().(float64)
+	if split != 2.5 {
+		t.Fatalf("Expecting split value 2.5, got %f", split)
+	}
+}
@@ -66,3 +66,52 @@ func StirlingS2(n int, k int) int {
 
 	return sum / Factorial(k)
 }
+
+/*
+RMSE compute the root of mean squared error between actual values and their
+predictions.
+
+Result is sqrt(sum((actuals[i] - predicts[i])^2) / n)
+*/
+func RMSE(actuals, predicts []float64) float64 {
+	// Make sure we are not looping out of range.
+	n := len(actuals)
+	if len(predicts) < n {
+		n = len(predicts)
+	}
+	if n == 0 {
+		return 0
+	}
+
+	var sum float64
+	for x := 0; x < n; x++ {
+		d := actuals[x] - predicts[x]
+		sum += d * d
+	}
+
+	return math.Sqrt(sum / float64(n))
+}
+
+/*
+MAE compute the mean absolute error between actual values and their
+predictions.
+
+Result is sum(|actuals[i] - predicts[i]|) / n
+*/
+func MAE(actuals, predicts []float64) float64 {
+	// Make sure we are not looping out of range.
+	n := len(actuals)
+	if len(predicts) < n {
+		n = len(predicts)
+	}
+	if n == 0 {
+		return 0
+	}
+
+	var sum float64
+	for x := 0; x < n; x++ {
+		sum += math.Abs(actuals[x] - predicts[x])
+	}
+
+	return sum / float64(n)
+}
@@ -48,3 +48,18 @@ func TestStirlingS2(t *testing.T) {
 		}
 	}
 }
+
+func TestRMSEAndMAE(t *testing.T) {
+	actuals := []float64{1, 2, 3, 4}
+	predicts := []float64{1, 4, 3, 2}
+
+	res := math.RMSE(actuals, predicts)
+	if res != 1.4142135623730951 {
+		t.Fatal("Expecting RMSE 1.4142135623730951, got ", res)
+	}
+
+	res = math.MAE(actuals, predicts)
+	if res != 1 {
+		t.Fatal("Expecting MAE 1, got ", res)
+	}
+}
@@ -5,7 +5,9 @@
 package binary_test
 
 import (
+	"bytes"
 	"fmt"
+	"reflect"
 	"testing"
 
 	"github.com/shuLhan/go-mining/tree/binary"
@@ -47,3 +49,166 @@ func TestTree(t *testing.T) {
 		t.Fatal("error, expecting:\n", exp, "\n got:\n", res)
 	}
 }
+
+func TestWriteDOT(t *testing.T) {
+	exp := `digraph "tree" {
+	n0 [shape=box, label="1"];
+	n1 [shape=ellipse, label="11"];
+	n0 -> n1 [label="yes"];
+	n2 [shape=box, label="12"];
+	n3 [shape=ellipse, label="21"];
+	n2 -> n3 [label="yes"];
+	n4 [shape=ellipse, label="\"22\""];
+	n2 -> n4 [label="no"];
+	n0 -> n2 [label="no"];
+}
+`
+
+	btree := binary.NewTree()
+
+	btree.Root = binary.NewBTNode(1,
+		binary.NewBTNode(11, nil, nil),
+		binary.NewBTNode(12,
+			binary.NewBTNode(21, nil, nil),
+			binary.NewBTNode(`"22"`, nil, nil)))
+
+	dot := binary.DOT{
+		LeftLabel:  "yes",
+		RightLabel: "no",
+	}
+
+	buf := bytes.Buffer{}
+
+	e := dot.Write(&buf, btree)
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	if buf.String() != exp {
+		t.Fatal("error, expecting:\n", exp, "\n got:\n", buf.String())
+	}
+}
+
+func newTestTree() *binary.Tree {
+	btree := binary.NewTree()
+
+	btree.Root = binary.NewBTNode(1,
+		binary.NewBTNode(11,
+			binary.NewBTNode(21, nil, nil),
+			binary.NewBTNode(22,
+				binary.NewBTNode(31, nil, nil),
+				binary.NewBTNode(32, nil, nil))),
+		binary.NewBTNode(12, nil, nil))
+
+	return btree
+}
+
+func walkValues(walk func(binary.WalkFunc) bool, stopAt int) (
+	values []int, completed bool,
+) {
+	completed = walk(func(node *binary.BTNode) bool {
+		values = append(values, node.Value.(int))
+		return node.Value.(int) != stopAt
+	})
+	return
+}
+
+func TestWalk(t *testing.T) {
+	btree := newTestTree()
+
+	cases := []struct {
+		desc string
+		walk func(binary.WalkFunc) bool
+		exp  []int
+	}{{
+		desc: "pre-order",
+		walk: btree.WalkPreOrder,
+		exp:  []int{1, 11, 21, 22, 31, 32, 12},
+	}, {
+		desc: "in-order",
+		walk: btree.WalkInOrder,
+		exp:  []int{21, 11, 31, 22, 32, 1, 12},
+	}, {
+		desc: "post-order",
+		walk: btree.WalkPostOrder,
+		exp:  []int{21, 31, 32, 22, 11, 12, 1},
+	}, {
+		desc: "level-order",
+		walk: btree.WalkLevelOrder,
+		exp:  []int{1, 11, 12, 21, 22, 31, 32},
+	}}
+
+	for _, c := range cases {
+		got, completed := walkValues(c.walk, -1)
+		if !reflect.DeepEqual(c.exp, got) || !completed {
+			t.Fatalf("%s: expecting %v, got %v", c.desc, c.exp, got)
+		}
+
+		// Stop walking at the third node.
+		got, completed = walkValues(c.walk, c.exp[2])
+		if !reflect.DeepEqual(c.exp[:3], got) || completed {
+			t.Fatalf("%s: expecting stop at %v, got %v", c.desc,
+				c.exp[:3], got)
+		}
+	}
+}
+
+func TestInspect(t *testing.T) {
+	btree := newTestTree()
+
+	if btree.NodeCount() != 7 {
+		t.Fatalf("Expecting 7 nodes, got %d", btree.NodeCount())
+	}
+	if btree.LeafCount() != 4 {
+		t.Fatalf("Expecting 4 leaves, got %d", btree.LeafCount())
+	}
+	if btree.Height() != 3 {
+		t.Fatalf("Expecting height 3, got %d", btree.Height())
+	}
+
+	node31 := btree.Root.Left.Right.Left
+	if node31.Depth() != 3 {
+		t.Fatalf("Expecting depth 3, got %d", node31.Depth())
+	}
+
+	var path []int
+	for _, node := range node31.PathToRoot() {
+		path = append(path, node.Value.(int))
+	}
+	if !reflect.DeepEqual([]int{31, 22, 11, 1}, path) {
+		t.Fatalf("Expecting path [31 22 11 1], got %v", path)
+	}
+}
+
+func TestReplaceDelete(t *testing.T) {
+	btree := newTestTree()
+
+	node22 := btree.Root.Left.Right
+	leaf := binary.NewBTNode(40, nil, nil)
+
+	if !btree.Replace(node22, leaf) {
+		t.Fatal("Expecting node replaced")
+	}
+	if btree.Root.Left.Right != leaf || leaf.Parent != btree.Root.Left {
+		t.Fatal("Expecting new node in the right of 11")
+	}
+	if btree.NodeCount() != 5 {
+		t.Fatalf("Expecting 5 nodes, got %d", btree.NodeCount())
+	}
+
+	// Replacing the removed subtree must fail.
+	if btree.Replace(node22, leaf) {
+		t.Fatal("Expecting replace failed on removed node")
+	}
+
+	if !btree.Delete(btree.Root.Right) {
+		t.Fatal("Expecting node deleted")
+	}
+	if btree.Root.Right != nil || btree.NodeCount() != 4 {
+		t.Fatalf("Expecting 4 nodes, got %d", btree.NodeCount())
+	}
+
+	if !btree.Delete(btree.Root) || btree.Root != nil {
+		t.Fatal("Expecting empty tree")
+	}
+}
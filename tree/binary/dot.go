@@ -0,0 +1,124 @@
+// Copyright 2016 Mhd Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package binary
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	// DefDOTName default name of graph in DOT.
+	DefDOTName = "tree"
+)
+
+/*
+DOT define the options for writing tree in Graphviz DOT language.
+*/
+type DOT struct {
+	// Name of graph. If its empty, DefDOTName will be used.
+	Name string
+	// LeftLabel define the label on edge to the left node.
+	LeftLabel string
+	// RightLabel define the label on edge to the right node.
+	RightLabel string
+	// Label return the label of node. If its nil, the value of node will
+	// be used as label.
+	Label func(node *BTNode) string
+}
+
+//
+// dotEscape will escape the quote, backslash, and new line in label, so it
+// can be written as DOT string.
+//
+func dotEscape(s string) string {
+	s = strings.Replace(s, `\`, `\\`, -1)
+	s = strings.Replace(s, `"`, `\"`, -1)
+	s = strings.Replace(s, "\n", `\n`, -1)
+	return s
+}
+
+/*
+Write will write the tree in DOT language to `w`.
+The internal node is drawn as box and the leaf is drawn as ellipse.
+*/
+func (dot *DOT) Write(w io.Writer, btree *Tree) (e error) {
+	name := dot.Name
+	if name == "" {
+		name = DefDOTName
+	}
+
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintf(bw, "digraph \"%s\" {\n", dotEscape(name))
+
+	if btree != nil && btree.Root != nil {
+		id := 0
+		dot.writeNode(bw, btree.Root, &id)
+	}
+
+	fmt.Fprintln(bw, "}")
+
+	return bw.Flush()
+}
+
+//
+// writeNode will write the node and all of its children, where each node is
+// identified by their order in pre-order traversal.
+// It will return the identifier of node.
+//
+func (dot *DOT) writeNode(w io.Writer, node *BTNode, id *int) int {
+	nodeID := *id
+	*id++
+
+	var label string
+	if dot.Label != nil {
+		label = dot.Label(node)
+	} else {
+		label = fmt.Sprint(node.Value)
+	}
+
+	shape := "box"
+	if node.Left == nil && node.Right == nil {
+		shape = "ellipse"
+	}
+
+	fmt.Fprintf(w, "\tn%d [shape=%s, label=\"%s\"];\n", nodeID, shape,
+		dotEscape(label))
+
+	if node.Left != nil {
+		childID := dot.writeNode(w, node.Left, id)
+		dot.writeEdge(w, nodeID, childID, dot.LeftLabel)
+	}
+	if node.Right != nil {
+		childID := dot.writeNode(w, node.Right, id)
+		dot.writeEdge(w, nodeID, childID, dot.RightLabel)
+	}
+
+	return nodeID
+}
+
+//
+// writeEdge will write the edge from node `from` to node `to`.
+//
+func (dot *DOT) writeEdge(w io.Writer, from, to int, label string) {
+	if label == "" {
+		fmt.Fprintf(w, "\tn%d -> n%d;\n", from, to)
+		return
+	}
+	fmt.Fprintf(w, "\tn%d -> n%d [label=\"%s\"];\n", from, to,
+		dotEscape(label))
+}
+
+/*
+WriteDOT will write the tree in DOT language to `w`, using the value of each
+node as label.
+*/
+func (btree *Tree) WriteDOT(w io.Writer) error {
+	dot := &DOT{}
+	return dot.Write(w, btree)
+}
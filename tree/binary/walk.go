@@ -0,0 +1,262 @@
+// Copyright 2016 Mhd Sulhan <ms@kilabit.info>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package binary
+
+/*
+WalkFunc define the function that will be called on each node when walking
+the tree. If its return false, the walk will be stopped.
+*/
+type WalkFunc func(node *BTNode) bool
+
+/*
+IsLeaf will return true if node does not have any children.
+*/
+func (n *BTNode) IsLeaf() bool {
+	return n.Left == nil && n.Right == nil
+}
+
+/*
+WalkPreOrder will call `fn` on node, and then on all nodes in left and right
+subtree.
+It will return false if the walk is stopped by `fn`.
+*/
+func (n *BTNode) WalkPreOrder(fn WalkFunc) bool {
+	if n == nil {
+		return true
+	}
+	if !fn(n) {
+		return false
+	}
+	if !n.Left.WalkPreOrder(fn) {
+		return false
+	}
+	return n.Right.WalkPreOrder(fn)
+}
+
+/*
+WalkInOrder will call `fn` on all nodes in left subtree, and then on node,
+and then on all nodes in right subtree.
+It will return false if the walk is stopped by `fn`.
+*/
+func (n *BTNode) WalkInOrder(fn WalkFunc) bool {
+	if n == nil {
+		return true
+	}
+	if !n.Left.WalkInOrder(fn) {
+		return false
+	}
+	if !fn(n) {
+		return false
+	}
+	return n.Right.WalkInOrder(fn)
+}
+
+/*
+WalkPostOrder will call `fn` on all nodes in left and right subtree, and then
+on node.
+It will return false if the walk is stopped by `fn`.
+*/
+func (n *BTNode) WalkPostOrder(fn WalkFunc) bool {
+	if n == nil {
+		return true
+	}
+	if !n.Left.WalkPostOrder(fn) {
+		return false
+	}
+	if !n.Right.WalkPostOrder(fn) {
+		return false
+	}
+	return fn(n)
+}
+
+/*
+WalkLevelOrder will call `fn` on node and all nodes in subtree, from the top
+level down to the bottom level, and from left to right in each level.
+It will return false if the walk is stopped by `fn`.
+*/
+func (n *BTNode) WalkLevelOrder(fn WalkFunc) bool {
+	if n == nil {
+		return true
+	}
+
+	queue := []*BTNode{n}
+
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+
+		if !fn(node) {
+			return false
+		}
+		if node.Left != nil {
+			queue = append(queue, node.Left)
+		}
+		if node.Right != nil {
+			queue = append(queue, node.Right)
+		}
+	}
+
+	return true
+}
+
+/*
+Depth return number of edges from node up to the root.
+*/
+func (n *BTNode) Depth() (depth int) {
+	for p := n.Parent; p != nil; p = p.Parent {
+		depth++
+	}
+	return
+}
+
+/*
+Height return number of edges in the longest path from node down to the leaf.
+*/
+func (n *BTNode) Height() int {
+	if n == nil || n.IsLeaf() {
+		return 0
+	}
+
+	left := -1
+	if n.Left != nil {
+		left = n.Left.Height()
+	}
+	right := -1
+	if n.Right != nil {
+		right = n.Right.Height()
+	}
+
+	if left > right {
+		return left + 1
+	}
+	return right + 1
+}
+
+/*
+NodeCount return number of nodes in subtree, including the node itself.
+*/
+func (n *BTNode) NodeCount() (count int) {
+	n.WalkPreOrder(func(node *BTNode) bool {
+		count++
+		return true
+	})
+	return
+}
+
+/*
+LeafCount return number of leaves in subtree.
+*/
+func (n *BTNode) LeafCount() (count int) {
+	n.WalkPreOrder(func(node *BTNode) bool {
+		if node.IsLeaf() {
+			count++
+		}
+		return true
+	})
+	return
+}
+
+/*
+PathToRoot return list of nodes from node up to the root, including the node
+itself.
+*/
+func (n *BTNode) PathToRoot() (path []*BTNode) {
+	for p := n; p != nil; p = p.Parent {
+		path = append(path, p)
+	}
+	return
+}
+
+/*
+WalkPreOrder will call `fn` on each node in tree using pre-order traversal.
+*/
+func (btree *Tree) WalkPreOrder(fn WalkFunc) bool {
+	return btree.Root.WalkPreOrder(fn)
+}
+
+/*
+WalkInOrder will call `fn` on each node in tree using in-order traversal.
+*/
+func (btree *Tree) WalkInOrder(fn WalkFunc) bool {
+	return btree.Root.WalkInOrder(fn)
+}
+
+/*
+WalkPostOrder will call `fn` on each node in tree using post-order traversal.
+*/
+func (btree *Tree) WalkPostOrder(fn WalkFunc) bool {
+	return btree.Root.WalkPostOrder(fn)
+}
+
+/*
+WalkLevelOrder will call `fn` on each node in tree using level-order
+traversal.
+*/
+func (btree *Tree) WalkLevelOrder(fn WalkFunc) bool {
+	return btree.Root.WalkLevelOrder(fn)
+}
+
+/*
+Height return the height of tree.
+*/
+func (btree *Tree) Height() int {
+	return btree.Root.Height()
+}
+
+/*
+NodeCount return number of nodes in tree.
+*/
+func (btree *Tree) NodeCount() int {
+	return btree.Root.NodeCount()
+}
+
+/*
+LeafCount return number of leaves in tree.
+*/
+func (btree *Tree) LeafCount() int {
+	return btree.Root.LeafCount()
+}
+
+/*
+Replace will replace the subtree `old` in tree with subtree `node`.
+If `node` is nil, the subtree `old` will be removed from tree.
+It will return false if `old` is not part of tree.
+*/
+func (btree *Tree) Replace(old, node *BTNode) bool {
+	if old == nil {
+		return false
+	}
+
+	parent := old.Parent
+
+	switch {
+	case parent == nil:
+		if btree.Root != old {
+			return false
+		}
+		btree.Root = node
+	case parent.Left == old:
+		parent.Left = node
+	case parent.Right == old:
+		parent.Right = node
+	default:
+		return false
+	}
+
+	if node != nil {
+		node.Parent = parent
+	}
+	old.Parent = nil
+
+	return true
+}
+
+/*
+Delete will remove the subtree `node` from tree.
+It will return false if `node` is not part of tree.
+*/
+func (btree *Tree) Delete(node *BTNode) bool {
+	return btree.Replace(node, nil)
+}